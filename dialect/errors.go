@@ -0,0 +1,126 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// MySQL error numbers mapped to ORM errors
+const (
+	mysqlTooManyConnections = 1040
+	mysqlServerShutdown     = 1053
+	mysqlNotNull            = 1048
+	mysqlNoDefault          = 1364
+	mysqlDuplicateEntry     = 1062
+	mysqlRowIsReferenced    = 1451
+	mysqlNoReferencedRow    = 1452
+)
+
+// TranslateError maps a MySQL driver error to the ORM's typed errors. Exec, Query and
+// transaction calls already do this; it serves errors surfacing from a *sql.Row scan.
+func (m *MySQLDialect) TranslateError(err error) error {
+	return translateMySQLError(err)
+}
+
+// TranslateError maps a PostgreSQL driver error to the ORM's typed errors. Exec, Query and
+// transaction calls already do this; it serves errors surfacing from a *sql.Row scan.
+func (p *PostgresDialect) TranslateError(err error) error {
+	return translatePostgresError(err)
+}
+
+// translateMySQLError wraps err with the ORM error matching its MySQL error number.
+// Errors without a match are returned unchanged.
+func translateMySQLError(err error) error {
+	if err == nil || isTranslated(err) {
+		return err
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return translateConnectionError(err)
+	}
+
+	switch mysqlErr.Number {
+	case mysqlDuplicateEntry:
+		return &interfaces.ErrUniqueViolation{Constraint: quoted(mysqlErr.Message, "for key '", "'"), Err: err}
+	case mysqlRowIsReferenced, mysqlNoReferencedRow:
+		return &interfaces.ErrForeignKeyViolation{Constraint: quoted(mysqlErr.Message, "CONSTRAINT `", "`"), Err: err}
+	case mysqlNotNull:
+		return &interfaces.ErrNotNullViolation{Column: quoted(mysqlErr.Message, "Column '", "'"), Err: err}
+	case mysqlNoDefault:
+		return &interfaces.ErrNotNullViolation{Column: quoted(mysqlErr.Message, "Field '", "'"), Err: err}
+	case mysqlDeadlock, mysqlLockWaitTimeout:
+		return fmt.Errorf("%w: %w", interfaces.ErrDeadlock, err)
+	case mysqlTooManyConnections, mysqlServerShutdown:
+		return fmt.Errorf("%w: %w", interfaces.ErrConnection, err)
+	}
+	return err
+}
+
+// translatePostgresError wraps err with the ORM error matching its SQLSTATE code.
+// Errors without a match are returned unchanged.
+func translatePostgresError(err error) error {
+	if err == nil || isTranslated(err) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return translateConnectionError(err)
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		return &interfaces.ErrUniqueViolation{Constraint: pqErr.Constraint, Err: err}
+	case "23503":
+		return &interfaces.ErrForeignKeyViolation{Constraint: pqErr.Constraint, Err: err}
+	case "23502":
+		return &interfaces.ErrNotNullViolation{Column: pqErr.Column, Err: err}
+	case postgresSerializationFailure, postgresDeadlockDetected:
+		return fmt.Errorf("%w: %w", interfaces.ErrDeadlock, err)
+	}
+	// Class 08 covers connection exceptions; 57P01-57P03 are server shutdowns
+	if pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03" {
+		return fmt.Errorf("%w: %w", interfaces.ErrConnection, err)
+	}
+	return err
+}
+
+// translateConnectionError wraps network and broken connection errors with ErrConnection
+func translateConnectionError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", interfaces.ErrConnection, err)
+	}
+	return err
+}
+
+// isTranslated reports whether err already carries an ORM error
+func isTranslated(err error) bool {
+	var unique *interfaces.ErrUniqueViolation
+	var foreignKey *interfaces.ErrForeignKeyViolation
+	var notNull *interfaces.ErrNotNullViolation
+	return errors.Is(err, interfaces.ErrDeadlock) || errors.Is(err, interfaces.ErrConnection) ||
+		errors.As(err, &unique) || errors.As(err, &foreignKey) || errors.As(err, &notNull)
+}
+
+// quoted returns the text of message between prefix and the next suffix, or ""
+func quoted(message, prefix, suffix string) string {
+	start := strings.Index(message, prefix)
+	if start < 0 {
+		return ""
+	}
+	rest := message[start+len(prefix):]
+	end := strings.Index(rest, suffix)
+	if end < 0 {
+		return ""
+	}
+	return rest[:end]
+}
@@ -3,6 +3,7 @@ package dialect
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,14 +14,17 @@ import (
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
 
 	"strconv"
+	"sync/atomic"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 )
 
 // MySQLDialect implements the interfaces.Dialect interface for MySQL
 type MySQLDialect struct {
-	db *sql.DB
+	db            *sql.DB
+	stmts         atomic.Pointer[StmtCache]
+	stmtCacheSize int
 }
 
 // NewMySQLDialect creates a new MySQL dialect instance
@@ -108,6 +112,12 @@ func (m *MySQLDialect) Connect(config interfaces.ConnectionConfig) error {
 	m.db.SetMaxIdleConns(5)
 	m.db.SetConnMaxLifetime(5 * time.Minute)
 
+	// Statements prepared on a previous connection pool are no longer valid
+	if config.StatementCacheSize > 0 {
+		m.stmtCacheSize = config.StatementCacheSize
+	}
+	m.resetStatementCache()
+
 	// Test connection
 	if err := m.Ping(); err != nil {
 		return fmt.Errorf("failed to ping MySQL: %w", err)
@@ -119,18 +129,53 @@ func (m *MySQLDialect) Connect(config interfaces.ConnectionConfig) error {
 
 // Close closes the database connection
 func (m *MySQLDialect) Close() error {
+	if stmts := m.stmts.Swap(nil); stmts != nil {
+		stmts.Close()
+	}
 	if m.db != nil {
 		return m.db.Close()
 	}
 	return nil
 }
 
+// DB returns the underlying connection pool, or nil before Connect
+func (m *MySQLDialect) DB() *sql.DB {
+	return m.db
+}
+
+// EnableStatementCache keeps up to capacity prepared statements; 0 or less disables it
+func (m *MySQLDialect) EnableStatementCache(capacity int) {
+	m.stmtCacheSize = capacity
+	m.resetStatementCache()
+}
+
+// StatementCacheStats returns the statement cache counters
+func (m *MySQLDialect) StatementCacheStats() StmtCacheStats {
+	stmts := m.stmts.Load()
+	if stmts == nil {
+		return StmtCacheStats{}
+	}
+	return stmts.Stats()
+}
+
+// resetStatementCache drops the cached statements and starts a new cache for the current pool
+func (m *MySQLDialect) resetStatementCache() {
+	var stmts *StmtCache
+	if m.stmtCacheSize > 0 && m.db != nil {
+		stmts = NewStmtCache(m.db, m.stmtCacheSize)
+	}
+	// Queries running meanwhile keep the statements they took from the old cache open
+	if old := m.stmts.Swap(stmts); old != nil {
+		old.Close()
+	}
+}
+
 // Ping tests the database connection
 func (m *MySQLDialect) Ping() error {
 	if m.db == nil {
 		return fmt.Errorf("database connection not established")
 	}
-	return m.db.Ping()
+	return translateMySQLError(m.db.Ping())
 }
 
 // Exec executes a query without returning rows
@@ -138,7 +183,12 @@ func (m *MySQLDialect) Exec(query string, args ...interface{}) (sql.Result, erro
 	if m.db == nil {
 		return nil, fmt.Errorf("database connection not established")
 	}
-	return m.db.Exec(query, args...)
+	if stmts := m.stmts.Load(); stmts != nil {
+		result, err := stmts.Exec(nil, query, args...)
+		return result, translateMySQLError(err)
+	}
+	result, err := m.db.Exec(query, args...)
+	return result, translateMySQLError(err)
 }
 
 // Query executes a query that returns rows
@@ -146,7 +196,12 @@ func (m *MySQLDialect) Query(query string, args ...interface{}) (*sql.Rows, erro
 	if m.db == nil {
 		return nil, fmt.Errorf("database connection not established")
 	}
-	return m.db.Query(query, args...)
+	if stmts := m.stmts.Load(); stmts != nil {
+		rows, err := stmts.Query(nil, query, args...)
+		return rows, translateMySQLError(err)
+	}
+	rows, err := m.db.Query(query, args...)
+	return rows, translateMySQLError(err)
 }
 
 // QueryRow executes a query that returns a single row
@@ -154,6 +209,9 @@ func (m *MySQLDialect) QueryRow(query string, args ...interface{}) *sql.Row {
 	if m.db == nil {
 		return nil
 	}
+	if stmts := m.stmts.Load(); stmts != nil {
+		return stmts.QueryRow(nil, query, args...)
+	}
 	return m.db.QueryRow(query, args...)
 }
 
@@ -164,9 +222,9 @@ func (m *MySQLDialect) Begin() (interfaces.Transaction, error) {
 	}
 	tx, err := m.db.Begin()
 	if err != nil {
-		return nil, err
+		return nil, translateMySQLError(err)
 	}
-	return &MySQLTransaction{tx: tx}, nil
+	return &MySQLTransaction{tx: tx, stmts: m.stmts.Load()}, nil
 }
 
 // BeginTx starts a new transaction with options
@@ -176,9 +234,25 @@ func (m *MySQLDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interf
 	}
 	tx, err := m.db.BeginTx(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, translateMySQLError(err)
 	}
-	return &MySQLTransaction{tx: tx}, nil
+	return &MySQLTransaction{tx: tx, stmts: m.stmts.Load()}, nil
+}
+
+// MySQL error numbers worth retrying a transaction for
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// IsRetryable reports whether err aborted a transaction that may succeed if run again:
+// a deadlock or a lock wait timeout
+func (m *MySQLDialect) IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
 }
 
 // CreateTable creates a table with the given columns
@@ -331,31 +405,52 @@ func (m *MySQLDialect) buildColumnDefinition(col interfaces.Column) string {
 
 // MySQLTransaction implements core.Transaction for MySQL
 type MySQLTransaction struct {
-	tx *sql.Tx
+	tx    *sql.Tx
+	stmts *StmtCache
 }
 
 // Commit commits the transaction
 func (mt *MySQLTransaction) Commit() error {
-	return mt.tx.Commit()
+	return translateMySQLError(mt.tx.Commit())
 }
 
 // Rollback rolls back the transaction
 func (mt *MySQLTransaction) Rollback() error {
-	return mt.tx.Rollback()
+	return translateMySQLError(mt.tx.Rollback())
 }
 
 // Exec executes a query within the transaction
 func (mt *MySQLTransaction) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return mt.tx.Exec(query, args...)
+	if mt.stmts != nil {
+		result, err := mt.stmts.Exec(mt.tx, query, args...)
+		return result, translateMySQLError(err)
+	}
+	result, err := mt.tx.Exec(query, args...)
+	return result, translateMySQLError(err)
+}
+
+// ExecContext executes a statement within the transaction without going through the
+// statement cache
+func (mt *MySQLTransaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := mt.tx.ExecContext(ctx, query, args...)
+	return result, translateMySQLError(err)
 }
 
 // Query executes a query that returns rows within the transaction
 func (mt *MySQLTransaction) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return mt.tx.Query(query, args...)
+	if mt.stmts != nil {
+		rows, err := mt.stmts.Query(mt.tx, query, args...)
+		return rows, translateMySQLError(err)
+	}
+	rows, err := mt.tx.Query(query, args...)
+	return rows, translateMySQLError(err)
 }
 
 // QueryRow executes a query that returns a single row within the transaction
 func (mt *MySQLTransaction) QueryRow(query string, args ...interface{}) *sql.Row {
+	if mt.stmts != nil {
+		return mt.stmts.QueryRow(mt.tx, query, args...)
+	}
 	return mt.tx.QueryRow(query, args...)
 }
 
@@ -3,22 +3,26 @@ package dialect
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // PostgresDialect implements the interfaces.Dialect interface for PostgreSQL
 type PostgresDialect struct {
-	db *sql.DB
+	db            *sql.DB
+	stmts         atomic.Pointer[StmtCache]
+	stmtCacheSize int
 }
 
 // NewPostgresDialect creates a new Postgres dialect instance
@@ -93,6 +97,12 @@ func (p *PostgresDialect) Connect(config interfaces.ConnectionConfig) error {
 	p.db.SetMaxOpenConns(25)
 	p.db.SetMaxIdleConns(5)
 	p.db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Statements prepared on a previous connection pool are no longer valid
+	if config.StatementCacheSize > 0 {
+		p.stmtCacheSize = config.StatementCacheSize
+	}
+	p.resetStatementCache()
 	if err := p.Ping(); err != nil {
 		return fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
@@ -101,37 +111,85 @@ func (p *PostgresDialect) Connect(config interfaces.ConnectionConfig) error {
 }
 
 func (p *PostgresDialect) Close() error {
+	if stmts := p.stmts.Swap(nil); stmts != nil {
+		stmts.Close()
+	}
 	if p.db != nil {
 		return p.db.Close()
 	}
 	return nil
 }
 
+// DB returns the underlying connection pool, or nil before Connect
+func (p *PostgresDialect) DB() *sql.DB {
+	return p.db
+}
+
+// EnableStatementCache keeps up to capacity prepared statements; 0 or less disables it
+func (p *PostgresDialect) EnableStatementCache(capacity int) {
+	p.stmtCacheSize = capacity
+	p.resetStatementCache()
+}
+
+// StatementCacheStats returns the statement cache counters
+func (p *PostgresDialect) StatementCacheStats() StmtCacheStats {
+	stmts := p.stmts.Load()
+	if stmts == nil {
+		return StmtCacheStats{}
+	}
+	return stmts.Stats()
+}
+
+// resetStatementCache drops the cached statements and starts a new cache for the current pool
+func (p *PostgresDialect) resetStatementCache() {
+	var stmts *StmtCache
+	if p.stmtCacheSize > 0 && p.db != nil {
+		stmts = NewStmtCache(p.db, p.stmtCacheSize)
+	}
+	// Queries running meanwhile keep the statements they took from the old cache open
+	if old := p.stmts.Swap(stmts); old != nil {
+		old.Close()
+	}
+}
+
 func (p *PostgresDialect) Ping() error {
 	if p.db == nil {
 		return fmt.Errorf("database connection not established")
 	}
-	return p.db.Ping()
+	return translatePostgresError(p.db.Ping())
 }
 
 func (p *PostgresDialect) Exec(query string, args ...interface{}) (sql.Result, error) {
 	if p.db == nil {
 		return nil, fmt.Errorf("database connection not established")
 	}
-	return p.db.Exec(query, args...)
+	if stmts := p.stmts.Load(); stmts != nil {
+		result, err := stmts.Exec(nil, query, args...)
+		return result, translatePostgresError(err)
+	}
+	result, err := p.db.Exec(query, args...)
+	return result, translatePostgresError(err)
 }
 
 func (p *PostgresDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	if p.db == nil {
 		return nil, fmt.Errorf("database connection not established")
 	}
-	return p.db.Query(query, args...)
+	if stmts := p.stmts.Load(); stmts != nil {
+		rows, err := stmts.Query(nil, query, args...)
+		return rows, translatePostgresError(err)
+	}
+	rows, err := p.db.Query(query, args...)
+	return rows, translatePostgresError(err)
 }
 
 func (p *PostgresDialect) QueryRow(query string, args ...interface{}) *sql.Row {
 	if p.db == nil {
 		return nil
 	}
+	if stmts := p.stmts.Load(); stmts != nil {
+		return stmts.QueryRow(nil, query, args...)
+	}
 	return p.db.QueryRow(query, args...)
 }
 
@@ -141,9 +199,9 @@ func (p *PostgresDialect) Begin() (interfaces.Transaction, error) {
 	}
 	tx, err := p.db.Begin()
 	if err != nil {
-		return nil, err
+		return nil, translatePostgresError(err)
 	}
-	return &PostgresTransaction{tx: tx}, nil
+	return &PostgresTransaction{tx: tx, stmts: p.stmts.Load()}, nil
 }
 
 func (p *PostgresDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
@@ -152,9 +210,32 @@ func (p *PostgresDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (int
 	}
 	tx, err := p.db.BeginTx(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, translatePostgresError(err)
 	}
-	return &PostgresTransaction{tx: tx}, nil
+	return &PostgresTransaction{tx: tx, stmts: p.stmts.Load()}, nil
+}
+
+// SetDeferrable makes a freshly started transaction DEFERRABLE. sql.TxOptions has no
+// such flag, so it is set with SET TRANSACTION before the first query.
+func (p *PostgresDialect) SetDeferrable(tx interfaces.Transaction) error {
+	_, err := tx.Exec("SET TRANSACTION DEFERRABLE")
+	return err
+}
+
+// PostgreSQL SQLSTATE codes worth retrying a transaction for
+const (
+	postgresSerializationFailure = "40001"
+	postgresDeadlockDetected     = "40P01"
+)
+
+// IsRetryable reports whether err aborted a transaction that may succeed if run again:
+// a serialization failure or a detected deadlock
+func (p *PostgresDialect) IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == postgresSerializationFailure || pqErr.Code == postgresDeadlockDetected
 }
 
 func (p *PostgresDialect) CreateTable(tableName string, columns []interfaces.Column) error {
@@ -287,25 +368,46 @@ func (p *PostgresDialect) buildColumnDefinition(col interfaces.Column) string {
 
 // PostgresTransaction implements core.Transaction for PostgreSQL
 type PostgresTransaction struct {
-	tx *sql.Tx
+	tx    *sql.Tx
+	stmts *StmtCache
 }
 
 func (pt *PostgresTransaction) Commit() error {
-	return pt.tx.Commit()
+	return translatePostgresError(pt.tx.Commit())
 }
 
 func (pt *PostgresTransaction) Rollback() error {
-	return pt.tx.Rollback()
+	return translatePostgresError(pt.tx.Rollback())
 }
 
 func (pt *PostgresTransaction) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return pt.tx.Exec(query, args...)
+	if pt.stmts != nil {
+		result, err := pt.stmts.Exec(pt.tx, query, args...)
+		return result, translatePostgresError(err)
+	}
+	result, err := pt.tx.Exec(query, args...)
+	return result, translatePostgresError(err)
+}
+
+// ExecContext executes a statement within the transaction without going through the
+// statement cache
+func (pt *PostgresTransaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := pt.tx.ExecContext(ctx, query, args...)
+	return result, translatePostgresError(err)
 }
 
 func (pt *PostgresTransaction) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return pt.tx.Query(query, args...)
+	if pt.stmts != nil {
+		rows, err := pt.stmts.Query(pt.tx, query, args...)
+		return rows, translatePostgresError(err)
+	}
+	rows, err := pt.tx.Query(query, args...)
+	return rows, translatePostgresError(err)
 }
 
 func (pt *PostgresTransaction) QueryRow(query string, args ...interface{}) *sql.Row {
+	if pt.stmts != nil {
+		return pt.stmts.QueryRow(pt.tx, query, args...)
+	}
 	return pt.tx.QueryRow(query, args...)
 }
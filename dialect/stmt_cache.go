@@ -0,0 +1,211 @@
+package dialect
+
+import (
+	"container/list"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// StmtCache is a bounded LRU of prepared statements keyed by SQL text.
+// It is shared by every goroutine using the connection pool and is safe for concurrent use.
+type StmtCache struct {
+	mu        sync.Mutex
+	db        *sql.DB
+	closed    bool
+	capacity  int
+	items     map[string]*list.Element
+	order     *list.List
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// StmtCacheStats reports how the statement cache performs
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// stmtEntry is a cached prepared statement. refs counts the callers running it; a
+// statement dropped from the cache while in use is closed by the last of them.
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	dropped bool
+}
+
+// NewStmtCache creates a cache holding at most capacity statements prepared on db
+func NewStmtCache(db *sql.DB, capacity int) *StmtCache {
+	return &StmtCache{
+		db:       db,
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Prepare returns the cached statement for query, preparing it on a miss, and the
+// function releasing it. The statement stays open until release is called, even when it
+// is evicted meanwhile. When tx is not nil the statement is rebound to the transaction
+// with tx.Stmt.
+func (c *StmtCache) Prepare(tx *sql.Tx, query string) (*sql.Stmt, func(), error) {
+	entry, err := c.acquire(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { c.release(entry) }
+	if tx != nil {
+		return tx.Stmt(entry.stmt), release, nil
+	}
+	return entry.stmt, release, nil
+}
+
+// Exec runs query through its cached statement, within tx when it is not nil
+func (c *StmtCache) Exec(tx *sql.Tx, query string, args ...interface{}) (sql.Result, error) {
+	stmt, release, err := c.Prepare(tx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.Exec(args...)
+}
+
+// Query runs query through its cached statement, within tx when it is not nil. The rows
+// keep the statement open until they are closed.
+func (c *StmtCache) Query(tx *sql.Tx, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, release, err := c.Prepare(tx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.Query(args...)
+}
+
+// QueryRow runs query through its cached statement, within tx when it is not nil. On a
+// prepare failure the plain query runs instead and surfaces the error when the row is scanned.
+func (c *StmtCache) QueryRow(tx *sql.Tx, query string, args ...interface{}) *sql.Row {
+	stmt, release, err := c.Prepare(tx, query)
+	if err != nil {
+		if tx != nil {
+			return tx.QueryRow(query, args...)
+		}
+		return c.db.QueryRow(query, args...)
+	}
+	defer release()
+	return stmt.QueryRow(args...)
+}
+
+// acquire returns the cache entry for query with one more reference
+func (c *StmtCache) acquire(query string) (*stmtEntry, error) {
+	c.mu.Lock()
+	if element, ok := c.items[query]; ok {
+		c.order.MoveToFront(element)
+		c.hits++
+		entry := element.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Prepare outside the lock so a slow round trip does not block cached statements
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		// A transaction begun before the cache was replaced must not refill it
+		c.mu.Unlock()
+		return &stmtEntry{query: query, stmt: stmt, refs: 1, dropped: true}, nil
+	}
+	if element, ok := c.items[query]; ok {
+		// Another goroutine prepared the same statement meanwhile
+		entry := element.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		stmt.Close()
+		return entry, nil
+	}
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.order.PushFront(entry)
+	var unused []*sql.Stmt
+	for c.order.Len() > c.capacity {
+		if old := c.removeElement(c.order.Back()); old != nil {
+			unused = append(unused, old)
+		}
+		c.evictions++
+	}
+	c.mu.Unlock()
+
+	for _, old := range unused {
+		old.Close()
+	}
+	return entry, nil
+}
+
+// release drops a reference taken by acquire, closing the statement when it was the
+// last one on an entry no longer cached
+func (c *StmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	entry.refs--
+	closing := entry.dropped && entry.refs == 0
+	c.mu.Unlock()
+
+	if closing {
+		entry.stmt.Close()
+	}
+}
+
+// Stats returns the cache counters
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return StmtCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+	}
+}
+
+// Close closes every cached statement. Statements in use are closed once released.
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	var stmts []*sql.Stmt
+	for c.order.Len() > 0 {
+		if stmt := c.removeElement(c.order.Back()); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, stmt := range stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// removeElement unlinks an entry and returns its statement for the caller to close, or
+// nil when it is still in use and its last release closes it
+func (c *StmtCache) removeElement(element *list.Element) *sql.Stmt {
+	entry := element.Value.(*stmtEntry)
+	c.order.Remove(element)
+	delete(c.items, entry.query)
+	entry.dropped = true
+	if entry.refs > 0 {
+		return nil
+	}
+	return entry.stmt
+}
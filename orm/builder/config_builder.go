@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/routing"
 	"github.com/ESGI-M2/GO/orm/factory"
 	"github.com/joho/godotenv"
 )
@@ -100,6 +102,23 @@ func (cb *ConfigBuilder) WithConnectionLifetime(lifetimeSeconds int) *ConfigBuil
 	return cb
 }
 
+// WithReplica adds a read replica sharing the primary's credentials and database
+func (cb *ConfigBuilder) WithReplica(host string, port int) *ConfigBuilder {
+	return cb.WithReplicaConfig(interfaces.ReplicaConfig{Host: host, Port: port})
+}
+
+// WithReplicaConfig adds a read replica; empty fields inherit the primary's settings
+func (cb *ConfigBuilder) WithReplicaConfig(replica interfaces.ReplicaConfig) *ConfigBuilder {
+	cb.config.Replicas = append(cb.config.Replicas, replica)
+	return cb
+}
+
+// WithReplicaStrategy sets how reads are spread across replicas: "round_robin" or "least_latency"
+func (cb *ConfigBuilder) WithReplicaStrategy(strategy string) *ConfigBuilder {
+	cb.config.ReplicaStrategy = strategy
+	return cb
+}
+
 // WithAutoCreateDatabase enables automatic database creation
 func (cb *ConfigBuilder) WithAutoCreateDatabase() *ConfigBuilder {
 	cb.autoCreate = true
@@ -157,6 +176,7 @@ func (cb *ConfigBuilder) fromMySQLEnv() {
 	if password := os.Getenv("MYSQL_PASSWORD"); password != "" {
 		cb.config.Password = password
 	}
+	cb.replicasFromEnv("MYSQL_REPLICAS")
 }
 
 // fromPostgresEnv loads PostgreSQL configuration from environment
@@ -178,6 +198,25 @@ func (cb *ConfigBuilder) fromPostgresEnv() {
 	if password := os.Getenv("POSTGRES_PASSWORD"); password != "" {
 		cb.config.Password = password
 	}
+	cb.replicasFromEnv("POSTGRES_REPLICAS")
+}
+
+// replicasFromEnv adds the replicas listed in a variable such as "replica1:3306,replica2"
+func (cb *ConfigBuilder) replicasFromEnv(variable string) {
+	for _, address := range strings.Split(os.Getenv(variable), ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+
+		host, port := address, 0
+		if i := strings.LastIndex(address, ":"); i >= 0 {
+			if p, err := strconv.Atoi(address[i+1:]); err == nil {
+				host, port = address[:i], p
+			}
+		}
+		cb.WithReplica(host, port)
+	}
 }
 
 // Build builds the configuration
@@ -190,6 +229,10 @@ func (cb *ConfigBuilder) Build() (interfaces.ConnectionConfig, factory.DialectTy
 		return cb.config, cb.dialectType, cb.autoCreate, fmt.Errorf("username is required")
 	}
 
+	if _, err := routing.ParseStrategy(cb.config.ReplicaStrategy); err != nil {
+		return cb.config, cb.dialectType, cb.autoCreate, err
+	}
+
 	return cb.config, cb.dialectType, cb.autoCreate, nil
 }
 
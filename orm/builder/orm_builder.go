@@ -1,12 +1,14 @@
 package builder
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"github.com/ESGI-M2/GO/orm/core/connection"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
 	"github.com/ESGI-M2/GO/orm/core/query"
+	"github.com/ESGI-M2/GO/orm/core/routing"
 	"github.com/ESGI-M2/GO/orm/factory"
 )
 
@@ -137,6 +139,14 @@ func (s *SimpleORM) Connect() error {
 		return fmt.Errorf("failed to create dialect: %w", err)
 	}
 
+	// Route reads to replicas when some are configured
+	if len(s.config.Replicas) > 0 {
+		s.dialect = routing.NewDialect(s.dialect, func() interfaces.Dialect {
+			replica, _ := dialectFactory.Create(s.dialectType)
+			return replica
+		})
+	}
+
 	// Create database if needed
 	if s.autoCreate && s.dialectType != factory.Mock {
 		dbCreator := factory.NewDatabaseCreator()
@@ -235,6 +245,21 @@ func (r *ErrorRepository) Pluck(field string) ([]interface{}, error)
 func (r *ErrorRepository) Value(field string) (interface{}, error)                      { return nil, r.err }
 func (r *ErrorRepository) Increment(field string, amount interface{}) error             { return r.err }
 func (r *ErrorRepository) Decrement(field string, amount interface{}) error             { return r.err }
+func (r *ErrorRepository) Load(id interface{}) (interface{}, error)                     { return nil, r.err }
+func (r *ErrorRepository) LoadBy(criteria map[string]interface{}) ([]interface{}, error) {
+	return nil, r.err
+}
+func (r *ErrorRepository) Changes(entity interface{}) interfaces.Changes { return nil }
+func (r *ErrorRepository) Fill(entity interface{}, data map[string]interface{}) error {
+	return r.err
+}
+func (r *ErrorRepository) CreateFrom(data map[string]interface{}) (interface{}, error) {
+	return nil, r.err
+}
+func (r *ErrorRepository) Validate(entity interface{}) error { return r.err }
+func (r *ErrorRepository) History(id interface{}) ([]interfaces.AuditEntry, error) {
+	return nil, r.err
+}
 
 // Repository creates a repository for the model
 func (s *SimpleORM) Repository(model interface{}) interfaces.Repository {
@@ -264,6 +289,38 @@ func (s *SimpleORM) Transaction(fn func(interfaces.ORM) error) error {
 	return s.orm.Transaction(fn)
 }
 
+// TransactionWithOptions executes a function within a transaction started with the given options
+func (s *SimpleORM) TransactionWithOptions(ctx context.Context, opts interfaces.TxOptions, fn func(interfaces.ORM) error) error {
+	if !s.connected {
+		return fmt.Errorf("SimpleORM not connected. Call Connect() first.")
+	}
+	return s.orm.TransactionWithOptions(ctx, opts, fn)
+}
+
+// TransactionWithRetry executes a function within a transaction, retrying on deadlocks and serialization failures
+func (s *SimpleORM) TransactionWithRetry(opts interfaces.RetryOptions, fn func(interfaces.ORM) error) error {
+	if !s.connected {
+		return fmt.Errorf("SimpleORM not connected. Call Connect() first.")
+	}
+	return s.orm.TransactionWithRetry(opts, fn)
+}
+
+// AfterCommit registers a callback to run once the current transaction commits
+func (s *SimpleORM) AfterCommit(fn func() error) error {
+	if !s.connected {
+		return fmt.Errorf("SimpleORM not connected. Call Connect() first.")
+	}
+	return s.orm.AfterCommit(fn)
+}
+
+// AfterRollback registers a callback to run if the current transaction rolls back
+func (s *SimpleORM) AfterRollback(fn func() error) error {
+	if !s.connected {
+		return fmt.Errorf("SimpleORM not connected. Call Connect() first.")
+	}
+	return s.orm.AfterRollback(fn)
+}
+
 // Close closes the database connection
 func (s *SimpleORM) Close() error {
 	if s.orm != nil {
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/casts"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Table is the table audit entries are written to
+const Table = "audit_log"
+
+// Redacted replaces the values of sensitive and encrypted columns in audit entries
+const Redacted = "[redacted]"
+
+// Columns describes the audit log table, created with CreateTable
+var Columns = []interfaces.Column{
+	{Name: "id", Type: "BIGINT", PrimaryKey: true, AutoIncrement: true},
+	{Name: "table_name", Type: "VARCHAR(255)", Index: true},
+	{Name: "record_id", Type: "VARCHAR(255)", Index: true},
+	{Name: "action", Type: "VARCHAR(32)"},
+	{Name: "old_values", Type: "TEXT", Nullable: true},
+	{Name: "new_values", Type: "TEXT", Nullable: true},
+	{Name: "actor", Type: "VARCHAR(255)", Nullable: true},
+	{Name: "created_at", Type: "TIMESTAMP"},
+}
+
+// CreateTable creates the audit log table
+func CreateTable(dialect interfaces.Dialect) error {
+	return dialect.CreateTable(Table, Columns)
+}
+
+type actorKey struct{}
+
+// WithActor returns a context recording actor, such as a user ID, as the author of the
+// changes made by ORMs using it, see ORM.WithContext
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor recorded in ctx, empty when there is none
+func Actor(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Record writes an audit entry through dialect, which must be the one that made the change
+// so that both commit or roll back together. CreatedAt defaults to now.
+func Record(dialect interfaces.Dialect, entry interfaces.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	oldValues, err := encode(entry.OldValues)
+	if err != nil {
+		return fmt.Errorf("failed to encode old values: %w", err)
+	}
+	newValues, err := encode(entry.NewValues)
+	if err != nil {
+		return fmt.Errorf("failed to encode new values: %w", err)
+	}
+
+	columns := []string{"table_name", "record_id", "action", "old_values", "new_values", "actor", "created_at"}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = dialect.GetPlaceholder(i)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	var actor interface{}
+	if entry.Actor != "" {
+		actor = entry.Actor
+	}
+	_, err = dialect.Exec(query, entry.Table, entry.RecordID, entry.Action, oldValues, newValues, actor, entry.CreatedAt)
+	return err
+}
+
+// History returns the audit entries of a record, oldest first. It does not check the
+// record's tenant, which is left to the repository.
+func History(dialect interfaces.Dialect, table string, id interface{}) ([]interfaces.AuditEntry, error) {
+	query := fmt.Sprintf("SELECT id, table_name, record_id, action, old_values, new_values, actor, created_at FROM %s WHERE table_name = %s AND record_id = %s ORDER BY id",
+		Table, dialect.GetPlaceholder(0), dialect.GetPlaceholder(1))
+	rows, err := dialect.Query(query, table, fmt.Sprint(id))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []interfaces.AuditEntry
+	for rows.Next() {
+		var entry interfaces.AuditEntry
+		var oldValues, newValues, actor sql.NullString
+		var createdAt interface{}
+		if err := rows.Scan(&entry.ID, &entry.Table, &entry.RecordID, &entry.Action, &oldValues, &newValues, &actor, &createdAt); err != nil {
+			return nil, err
+		}
+		if entry.OldValues, err = decode(oldValues); err != nil {
+			return nil, fmt.Errorf("failed to decode old values of audit entry %d: %w", entry.ID, err)
+		}
+		if entry.NewValues, err = decode(newValues); err != nil {
+			return nil, fmt.Errorf("failed to decode new values of audit entry %d: %w", entry.ID, err)
+		}
+		entry.Actor = actor.String
+
+		// Drivers hand out timestamps as times or as text
+		if createdAt != nil {
+			value, err := casts.Get("datetime", createdAt, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the time of audit entry %d: %w", entry.ID, err)
+			}
+			entry.CreatedAt = value.(time.Time)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// encode returns values as a JSON document, or nil for none
+func encode(values map[string]interface{}) (interface{}, error) {
+	if values == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// decode parses a JSON document of values, nil for NULL
+func decode(document sql.NullString) (map[string]interface{}, error) {
+	if !document.Valid || document.String == "" {
+		return nil, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(document.String), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// DefaultCapacity is the number of entries kept by the ORM's default cache
+const DefaultCapacity = 1024
+
+// LRU is an in-memory least-recently-used cache with per-entry TTL and tags.
+// It is safe for concurrent use.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	tags     map[string]map[string]struct{}
+	now      func() time.Time
+}
+
+// entry represents a cached value
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+	tags      []string
+}
+
+// NewLRU creates a new LRU cache holding at most capacity entries
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &LRU{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		tags:     make(map[string]map[string]struct{}),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored under key if present and not expired
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if c.expired(e) {
+		c.removeElement(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set stores a value for ttl seconds (0 or less keeps it until evicted)
+func (c *LRU) Set(key string, value interface{}, ttl int) error {
+	return c.SetWithTags(key, value, ttl, nil)
+}
+
+// SetWithTags stores a value and associates it with tags for later invalidation
+func (c *LRU) SetWithTags(key string, value interface{}, ttl int, tags []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+
+	e := &entry{
+		key:   key,
+		value: value,
+		tags:  tags,
+	}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(time.Duration(ttl) * time.Second)
+	}
+
+	c.items[key] = c.order.PushFront(e)
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the value stored under key
+func (c *LRU) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	return nil
+}
+
+// Clear removes every entry
+func (c *LRU) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.tags = make(map[string]map[string]struct{})
+	return nil
+}
+
+// Has reports whether a live entry exists for key
+func (c *LRU) Has(key string) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// InvalidateTags removes every entry associated with one of the tags
+func (c *LRU) InvalidateTags(tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if element, ok := c.items[key]; ok {
+				c.removeElement(element)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+// Len returns the number of entries currently held, including expired ones not yet evicted
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// expired reports whether an entry has outlived its TTL
+func (c *LRU) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && c.now().After(e.expiresAt)
+}
+
+// removeElement unlinks an entry from the list, the index and its tags
+func (c *LRU) removeElement(element *list.Element) {
+	e := element.Value.(*entry)
+	c.order.Remove(element)
+	delete(c.items, e.key)
+	for _, tag := range e.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
+
+// Invalidate drops the entries tagged with the given tables.
+// Caches that do not support tags are cleared entirely.
+func Invalidate(c interfaces.Cache, tables ...string) error {
+	if c == nil || len(tables) == 0 {
+		return nil
+	}
+	if tagged, ok := c.(interfaces.TaggedCache); ok {
+		return tagged.InvalidateTags(tables...)
+	}
+	return c.Clear()
+}
@@ -0,0 +1,431 @@
+package casts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Caster converts a column value between its database and Go representations
+type Caster interface {
+	// Get converts a value read from the database. target is the type of the struct field
+	// being hydrated, or nil for map results. Values already converted must pass through.
+	Get(value interface{}, target reflect.Type) (interface{}, error)
+	// Set converts a Go value to the value written to the database
+	Set(value interface{}) (interface{}, error)
+}
+
+// Serializer is implemented by casts whose serialized form differs from the hydrated
+// value, such as datetimes rendered with their layout
+type Serializer interface {
+	Serialize(value interface{}) (interface{}, error)
+}
+
+// Factory builds a caster from the argument following the cast name in a tag, such as
+// the layout in `cast:datetime:2006-01-02`
+type Factory func(arg string) (Caster, error)
+
+// DefaultLayout is the datetime layout used when a datetime cast names none
+const DefaultLayout = "2006-01-02 15:04:05"
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{
+		"bool":  static(boolCaster{}),
+		"int":   static(intCaster{}),
+		"float": static(floatCaster{}),
+		"decimal": func(arg string) (Caster, error) {
+			if arg == "" {
+				return decimalCaster{scale: -1}, nil
+			}
+			scale, err := strconv.Atoi(arg)
+			if err != nil || scale < 0 {
+				return nil, fmt.Errorf("invalid decimal scale %q", arg)
+			}
+			return decimalCaster{scale: scale}, nil
+		},
+		"datetime": func(arg string) (Caster, error) {
+			if arg == "" {
+				arg = DefaultLayout
+			}
+			return datetimeCaster{layout: arg}, nil
+		},
+		"date":      static(datetimeCaster{layout: time.DateOnly}),
+		"timestamp": static(timestampCaster{}),
+		"string":    static(stringCaster{}),
+		"json":      static(jsonCaster{}),
+		"csv-array": static(csvCaster{}),
+	}
+)
+
+// Register adds a custom cast usable as `cast:<name>`, replacing any cast of that name
+func Register(name string, caster Caster) {
+	RegisterFactory(name, static(caster))
+}
+
+// RegisterFactory adds a custom cast taking an argument, usable as `cast:<name>:<arg>`
+func RegisterFactory(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+// Lookup returns the caster for a cast spec such as "json" or "datetime:2006-01-02"
+func Lookup(spec string) (Caster, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown cast %q", name)
+	}
+	return factory(arg)
+}
+
+// Get converts a database value with the cast named by spec. nil stays nil.
+func Get(spec string, value interface{}, target reflect.Type) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	caster, err := Lookup(spec)
+	if err != nil {
+		return nil, err
+	}
+	return caster.Get(value, target)
+}
+
+// Set converts a Go value for the database with the cast named by spec. Pointers are
+// dereferenced and nil stays nil.
+func Set(spec string, value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	caster, err := Lookup(spec)
+	if err != nil {
+		return nil, err
+	}
+	return caster.Set(v.Interface())
+}
+
+// Serialize converts a Go value for serialized output with the cast named by spec: the
+// cast's Serializer when it has one, its hydrated form otherwise
+func Serialize(spec string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	caster, err := Lookup(spec)
+	if err != nil {
+		return nil, err
+	}
+	if serializer, ok := caster.(Serializer); ok {
+		return serializer.Serialize(value)
+	}
+	return caster.Get(value, nil)
+}
+
+// static returns a factory ignoring its argument
+func static(caster Caster) Factory {
+	return func(string) (Caster, error) { return caster, nil }
+}
+
+// text returns the string form of a driver value
+func text(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// boolCaster reads booleans stored as integers or text, as MySQL's TINYINT(1) does
+type boolCaster struct{}
+
+func (boolCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if s, ok := text(value); ok {
+		return strconv.ParseBool(strings.TrimSpace(s))
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() != 0, nil
+	}
+	return nil, fmt.Errorf("cannot cast %T to bool", value)
+}
+
+func (c boolCaster) Set(value interface{}) (interface{}, error) {
+	return c.Get(value, nil)
+}
+
+// intCaster reads integers returned as text or floats
+type intCaster struct{}
+
+func (intCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if s, ok := text(value); ok {
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return nil, fmt.Errorf("cannot cast %T to int", value)
+}
+
+func (c intCaster) Set(value interface{}) (interface{}, error) {
+	return c.Get(value, nil)
+}
+
+// floatCaster reads floating point numbers returned as text
+type floatCaster struct{}
+
+func (floatCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if s, ok := text(value); ok {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return nil, fmt.Errorf("cannot cast %T to float", value)
+}
+
+func (c floatCaster) Set(value interface{}) (interface{}, error) {
+	return c.Get(value, nil)
+}
+
+// decimalCaster keeps exact decimals as strings, rounded to scale digits when scale is not
+// negative. Float fields receive a float64.
+type decimalCaster struct {
+	scale int
+}
+
+func (c decimalCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	s, err := c.format(value)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil && (target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64) {
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+func (c decimalCaster) Set(value interface{}) (interface{}, error) {
+	return c.format(value)
+}
+
+// format renders a decimal value as a string
+func (c decimalCaster) format(value interface{}) (string, error) {
+	var s string
+	switch v := value.(type) {
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		if t, ok := text(value); ok {
+			s = strings.TrimSpace(t)
+		} else {
+			s = fmt.Sprint(value)
+		}
+	}
+
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("cannot cast %q to decimal", s)
+	}
+	if c.scale < 0 {
+		return s, nil
+	}
+	return rat.FloatString(c.scale), nil
+}
+
+// datetimeCaster parses and formats times with a layout
+type datetimeCaster struct {
+	layout string
+}
+
+func (c datetimeCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot cast %T to datetime", value)
+	}
+	t, err := time.Parse(c.layout, strings.TrimSpace(s))
+	if err != nil {
+		// Drivers parsing times themselves may hand out RFC 3339 text
+		if t, rfcErr := time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); rfcErr == nil {
+			return t, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (c datetimeCaster) Set(value interface{}) (interface{}, error) {
+	t, err := c.Get(value, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.(time.Time).Format(c.layout), nil
+}
+
+func (c datetimeCaster) Serialize(value interface{}) (interface{}, error) {
+	return c.Set(value)
+}
+
+// timestampCaster stores times as Unix seconds
+type timestampCaster struct{}
+
+func (timestampCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	seconds, err := intCaster{}.Get(value, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot cast %T to timestamp", value)
+	}
+	return time.Unix(seconds.(int64), 0).UTC(), nil
+}
+
+func (c timestampCaster) Set(value interface{}) (interface{}, error) {
+	t, err := c.Get(value, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.(time.Time).Unix(), nil
+}
+
+func (c timestampCaster) Serialize(value interface{}) (interface{}, error) {
+	return c.Set(value)
+}
+
+// stringCaster reads text returned as bytes and formats other values
+type stringCaster struct{}
+
+func (stringCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if s, ok := text(value); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func (c stringCaster) Set(value interface{}) (interface{}, error) {
+	return c.Get(value, nil)
+}
+
+// jsonCaster stores values as JSON documents
+type jsonCaster struct{}
+
+func (jsonCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	s, ok := text(value)
+	if !ok {
+		// A value decoded for a map result is encoded again when the field wants another type
+		if target == nil || reflect.TypeOf(value).AssignableTo(target) {
+			return value, nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		s = string(encoded)
+	}
+	// A string field keeps the raw document
+	if target != nil && target.Kind() == reflect.String {
+		return s, nil
+	}
+	if target == nil {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+
+	for target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	decoded := reflect.New(target)
+	if err := json.Unmarshal([]byte(s), decoded.Interface()); err != nil {
+		return nil, err
+	}
+	return decoded.Elem().Interface(), nil
+}
+
+func (jsonCaster) Set(value interface{}) (interface{}, error) {
+	// Text is taken to be an encoded document already
+	if s, ok := text(value); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// csvCaster stores string lists as comma separated text
+type csvCaster struct{}
+
+func (csvCaster) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if list, ok := value.([]string); ok {
+		return list, nil
+	}
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot cast %T to csv-array", value)
+	}
+	if s == "" {
+		return []string{}, nil
+	}
+	list := strings.Split(s, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list, nil
+}
+
+func (csvCaster) Set(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ","), nil
+	case string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("cannot cast %T to csv-array", value)
+}
@@ -3,14 +3,26 @@ package connection
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ESGI-M2/GO/orm/core/cache"
+	"github.com/ESGI-M2/GO/orm/core/events"
+	"github.com/ESGI-M2/GO/orm/core/interceptor"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/logging"
 	"github.com/ESGI-M2/GO/orm/core/metadata"
 	"github.com/ESGI-M2/GO/orm/core/query"
 	"github.com/ESGI-M2/GO/orm/core/repository"
+	"github.com/ESGI-M2/GO/orm/core/sharding"
+	"github.com/ESGI-M2/GO/orm/core/singleflight"
 )
 
 // ORMImpl implements the ORM interface
@@ -20,6 +32,31 @@ type ORMImpl struct {
 	Models          map[reflect.Type]*interfaces.ModelMetadata
 	Connected       bool
 	mu              sync.RWMutex
+
+	cache     interfaces.Cache
+	cacheTTL  int
+	tx        *txState
+	coalescer *singleflight.Group
+
+	queryLog    *logging.Logger
+	queryBuffer *logging.RingBuffer
+	logQueries  bool
+
+	interceptors []interfaces.Interceptor
+	events       *events.Dispatcher
+
+	// ctx is handed to model hooks, and to transactions started without one
+	ctx context.Context
+}
+
+// txState tracks what a transaction touched so it can be settled on commit, along with
+// the callbacks to run once it is settled. Savepoints have their own state, merged into
+// the enclosing transaction's when released.
+type txState struct {
+	mu            sync.Mutex
+	tables        map[string]struct{}
+	afterCommit   []func() error
+	afterRollback []func() error
 }
 
 // NewORM creates a new ORM instance
@@ -29,6 +66,7 @@ func NewORM(dialect interfaces.Dialect) *ORMImpl {
 		MetadataManager: metadata.NewManager(),
 		Models:          make(map[reflect.Type]*interfaces.ModelMetadata),
 		Connected:       false,
+		events:          events.NewDispatcher(events.DefaultWorkers),
 	}
 }
 
@@ -45,12 +83,29 @@ func (o *ORMImpl) Connect(config interfaces.ConnectionConfig) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if config.CacheTTL > 0 {
+		if o.cache == nil {
+			o.cache = cache.NewLRU(cache.DefaultCapacity)
+		}
+		o.cacheTTL = config.CacheTTL
+	}
+
+	if config.EnableQueryLog {
+		o.ensureQueryLogger()
+		o.logQueries = true
+	}
+
 	o.Connected = true
 	return nil
 }
 
 // Close closes the database connection
 func (o *ORMImpl) Close() error {
+	// Deliver pending events first; their listeners may still use the ORM
+	if o.events != nil && o.tx == nil {
+		o.events.Close()
+	}
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -73,7 +128,130 @@ func (o *ORMImpl) IsConnected() bool {
 
 // GetDialect returns the current dialect
 func (o *ORMImpl) GetDialect() interfaces.Dialect {
-	return o.Dialect
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.wrapDialect(o.Dialect)
+}
+
+// PrimaryDialect returns the dialect bypassing read replicas, for read-your-writes queries
+func (o *ORMImpl) PrimaryDialect() interfaces.Dialect {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	d := o.Dialect
+	if router, ok := d.(interface{ Primary() interfaces.Dialect }); ok {
+		d = router.Primary()
+	}
+	return o.wrapDialect(d)
+}
+
+// ShardDialects returns the shards a query on table must visit, or nil when the table is not sharded
+func (o *ORMImpl) ShardDialects(table string, where []interfaces.WhereCondition) ([]interfaces.Dialect, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	cluster := o.sharding()
+	if cluster == nil {
+		return nil, nil
+	}
+	if _, ok := cluster.Rule(table); ok && o.tx != nil {
+		return nil, fmt.Errorf("sharded table %s cannot be used inside a transaction", table)
+	}
+
+	shards, err := cluster.Route(table, where)
+	if err != nil {
+		return nil, err
+	}
+	for i, shard := range shards {
+		shards[i] = o.wrapDialect(shard)
+	}
+	return shards, nil
+}
+
+// ShardDialect returns the shard holding the row of table with the given shard-key value,
+// or nil when the table is not sharded
+func (o *ORMImpl) ShardDialect(table string, key interface{}) (interfaces.Dialect, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	cluster := o.sharding()
+	if cluster == nil {
+		return nil, nil
+	}
+	if _, ok := cluster.Rule(table); !ok {
+		return nil, nil
+	}
+	if o.tx != nil {
+		return nil, fmt.Errorf("sharded table %s cannot be used inside a transaction", table)
+	}
+
+	shard, err := cluster.Locate(table, key)
+	if err != nil {
+		return nil, err
+	}
+	return o.wrapDialect(shard), nil
+}
+
+// ShardKey returns the shard-key column of table, or "" when the table is not sharded
+func (o *ORMImpl) ShardKey(table string) string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if cluster := o.sharding(); cluster != nil {
+		if rule, ok := cluster.Rule(table); ok {
+			return rule.Column
+		}
+	}
+	return ""
+}
+
+// sharding returns the sharding dialect behind o.Dialect, if any; o.mu must be held
+func (o *ORMImpl) sharding() *sharding.Dialect {
+	d := o.Dialect
+	for d != nil {
+		if cluster, ok := d.(*sharding.Dialect); ok {
+			return cluster
+		}
+		wrapper, ok := d.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			return nil
+		}
+		d = wrapper.Unwrap()
+	}
+	return nil
+}
+
+// wrapDialect applies the interceptor chain to d; o.mu must be held
+func (o *ORMImpl) wrapDialect(d interfaces.Dialect) interfaces.Dialect {
+	// Transaction-scoped ORMs run on a transaction that already goes through the chain
+	if o.tx != nil {
+		return d
+	}
+
+	interceptors := o.interceptorChain()
+	if len(interceptors) == 0 {
+		return d
+	}
+	return interceptor.NewDialect(o.Context(), d, interceptors)
+}
+
+// Use appends interceptors wrapping every Exec, Query, QueryRow, Begin, Commit and Rollback.
+// Interceptors run in the order they were added; transactions keep the chain they started with.
+func (o *ORMImpl) Use(interceptors ...interfaces.Interceptor) interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.interceptors = append(o.interceptors, interceptors...)
+	return o
+}
+
+// interceptorChain returns the interceptors applied to database calls, query logging last; o.mu must be held
+func (o *ORMImpl) interceptorChain() []interfaces.Interceptor {
+	chain := append([]interfaces.Interceptor(nil), o.interceptors...)
+	if o.logQueries {
+		chain = append(chain, o.queryLog.Intercept)
+	}
+	return chain
 }
 
 // RegisterModel registers a model with the ORM
@@ -87,6 +265,9 @@ func (o *ORMImpl) RegisterModel(model interface{}) error {
 	}
 
 	o.Models[metadata.Type] = metadata
+	if o.queryLog != nil {
+		o.queryLog.AddSensitiveColumns(sensitiveColumns(metadata)...)
+	}
 	return nil
 }
 
@@ -110,7 +291,11 @@ func (o *ORMImpl) Query(model interface{}) interfaces.QueryBuilder {
 		}
 	}
 
-	return query.NewBuilder(o, metadata)
+	builder := query.NewBuilder(o, metadata)
+	if ttl := o.defaultCacheTTL(); ttl > 0 {
+		builder.Cache(ttl)
+	}
+	return builder
 }
 
 // Raw creates a raw SQL query builder
@@ -130,22 +315,152 @@ func (o *ORMImpl) Repository(model interface{}) interfaces.Repository {
 
 // Transaction executes a function within a transaction
 func (o *ORMImpl) Transaction(fn func(interfaces.ORM) error) error {
-	tx, err := o.Dialect.Begin()
+	tx, err := o.GetDialect().Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	// Create a transaction-scoped ORM
-	txORM := &ORMImpl{
-		Dialect:         &TransactionDialect{tx: tx, dialect: o.Dialect},
-		MetadataManager: o.MetadataManager,
-		Models:          o.Models,
-		Connected:       true,
+	return o.runTransaction(o.Context(), tx, false, fn)
+}
+
+// TransactionWithContext executes a function within a transaction with context
+func (o *ORMImpl) TransactionWithContext(ctx context.Context, fn func(interfaces.ORM) error) error {
+	return o.TransactionWithOptions(ctx, interfaces.TxOptions{}, fn)
+}
+
+// TransactionWithOptions executes a function within a transaction started with the given
+// isolation level, read-only and deferrable flags. Writes in a read-only transaction fail
+// with ErrReadOnlyTransaction without reaching the database. Deferrable only applies to
+// PostgreSQL and is ignored by other dialects.
+func (o *ORMImpl) TransactionWithOptions(ctx context.Context, opts interfaces.TxOptions, fn func(interfaces.ORM) error) error {
+	if ctx == nil {
+		ctx = o.Context()
 	}
+	if o.tx != nil && opts.Deferrable {
+		return fmt.Errorf("failed to begin transaction: nested transactions cannot change transaction options")
+	}
+
+	var txOpts *sql.TxOptions
+	if opts.Isolation != sql.LevelDefault || opts.ReadOnly {
+		txOpts = &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	}
+	tx, err := o.GetDialect().BeginTx(ctx, txOpts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if opts.Deferrable {
+		if setter := o.deferrableSetter(); setter != nil {
+			if err := setter(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to make transaction deferrable: %w", err)
+			}
+		}
+	}
+
+	return o.runTransaction(ctx, tx, opts.ReadOnly, fn)
+}
+
+// deferrableSetter returns the dialect's way of making a transaction deferrable, looking
+// through dialect wrappers, or nil when the dialect has none
+func (o *ORMImpl) deferrableSetter() func(interfaces.Transaction) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	d := o.Dialect
+	for d != nil {
+		if setter, ok := d.(interface {
+			SetDeferrable(tx interfaces.Transaction) error
+		}); ok {
+			return setter.SetDeferrable
+		}
+		wrapper, ok := d.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	return nil
+}
+
+// Retry defaults used by TransactionWithRetry
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 10 * time.Millisecond
+	defaultRetryMaxDelay  = time.Second
+)
+
+// TransactionWithRetry runs fn in a transaction, running it again in a new transaction
+// when it fails with an error the dialect reports as retryable, such as a deadlock or a
+// serialization failure. Retries wait with exponential backoff and jitter.
+// Inside a transaction fn runs once in a savepoint: a deadlock has already rolled back the
+// whole transaction on the server, so only the outermost transaction can be retried.
+func (o *ORMImpl) TransactionWithRetry(opts interfaces.RetryOptions, fn func(interfaces.ORM) error) error {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultRetryAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultRetryBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultRetryMaxDelay
+	}
+	if opts.Context == nil {
+		opts.Context = o.Context()
+	}
+	if o.tx != nil {
+		return o.TransactionWithContext(opts.Context, fn)
+	}
+	if opts.Retryable == nil {
+		opts.Retryable = o.retryClassifier()
+	}
+
+	delay := opts.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := o.TransactionWithContext(opts.Context, fn)
+		if err == nil || attempt >= opts.MaxAttempts || !opts.Retryable(err) {
+			return err
+		}
+
+		// Equal jitter: wait between half and all of the current delay
+		wait := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-opts.Context.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, opts.MaxDelay)
+	}
+}
+
+// retryClassifier returns the dialect's classifier of retryable errors, looking through
+// dialect wrappers; without one, no error is retried
+func (o *ORMImpl) retryClassifier() func(error) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	d := o.Dialect
+	for d != nil {
+		if classifier, ok := d.(interface{ IsRetryable(err error) bool }); ok {
+			return classifier.IsRetryable
+		}
+		wrapper, ok := d.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	return func(error) bool { return false }
+}
+
+// runTransaction runs fn against a transaction-scoped ORM and commits or rolls back
+func (o *ORMImpl) runTransaction(ctx context.Context, tx interfaces.Transaction, readOnly bool, fn func(interfaces.ORM) error) error {
+	txORM := o.newTransactionORM(ctx, tx, readOnly)
 
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
+			txORM.tx.rolledBack()
 			panic(r)
 		}
 	}()
@@ -154,42 +469,191 @@ func (o *ORMImpl) Transaction(fn func(interfaces.ORM) error) error {
 		if rbErr := tx.Rollback(); rbErr != nil {
 			return fmt.Errorf("transaction failed: %w, rollback failed: %v", err, rbErr)
 		}
+		if cbErr := txORM.tx.rolledBack(); cbErr != nil {
+			return errors.Join(err, fmt.Errorf("after rollback: %w", cbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if cbErr := txORM.tx.rolledBack(); cbErr != nil {
+			return errors.Join(err, fmt.Errorf("after rollback: %w", cbErr))
+		}
 		return err
 	}
 
-	return tx.Commit()
+	// A released savepoint hands its tables and callbacks to the enclosing transaction
+	if o.tx != nil {
+		txORM.tx.mergeInto(o.tx)
+		return nil
+	}
+
+	// Readers may have cached pre-commit rows while the transaction was open
+	if err := cache.Invalidate(o.GetCache(), txORM.tx.touchedTables()...); err != nil {
+		return err
+	}
+	if err := txORM.tx.committed(); err != nil {
+		return fmt.Errorf("transaction committed, after commit: %w", err)
+	}
+	return nil
 }
 
-// TransactionWithContext executes a function within a transaction with context
-func (o *ORMImpl) TransactionWithContext(ctx context.Context, fn func(interfaces.ORM) error) error {
-	tx, err := o.Dialect.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// newTransactionORM creates a transaction-scoped ORM sharing models and features with o
+func (o *ORMImpl) newTransactionORM(ctx context.Context, tx interfaces.Transaction, readOnly bool) *ORMImpl {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	txDialect := &TransactionDialect{tx: tx, dialect: o.Dialect, savepoints: new(atomic.Int64), readOnly: readOnly}
+	if parent, ok := o.Dialect.(*TransactionDialect); ok {
+		// Savepoints are numbered across the whole transaction
+		txDialect.dialect = parent.dialect
+		txDialect.savepoints = parent.savepoints
+		txDialect.readOnly = parent.readOnly || readOnly
 	}
 
-	// Create a transaction-scoped ORM
-	txORM := &ORMImpl{
-		Dialect:         &TransactionDialect{tx: tx, dialect: o.Dialect},
+	return &ORMImpl{
+		Dialect:         txDialect,
 		MetadataManager: o.MetadataManager,
 		Models:          o.Models,
 		Connected:       true,
+		cache:           o.cache,
+		tx:              &txState{tables: make(map[string]struct{})},
+		queryLog:        o.queryLog,
+		queryBuffer:     o.queryBuffer,
+		logQueries:      o.logQueries,
+		events:          o.events,
+		ctx:             ctx,
 	}
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		}
-	}()
+// WithContext returns an ORM carrying ctx, which model hooks receive and transactions
+// started without a context use. It shares o's connection, models, cache and transaction;
+// settings changed on either afterwards are not shared.
+func (o *ORMImpl) WithContext(ctx context.Context) interfaces.ORM {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
 
-	if err := fn(txORM); err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("transaction failed: %w, rollback failed: %v", err, rbErr)
+	return &ORMImpl{
+		Dialect:         o.Dialect,
+		MetadataManager: o.MetadataManager,
+		Models:          o.Models,
+		Connected:       o.Connected,
+		cache:           o.cache,
+		cacheTTL:        o.cacheTTL,
+		tx:              o.tx,
+		coalescer:       o.coalescer,
+		queryLog:        o.queryLog,
+		queryBuffer:     o.queryBuffer,
+		logQueries:      o.logQueries,
+		interceptors:    o.interceptors,
+		events:          o.events,
+		ctx:             ctx,
+	}
+}
+
+// Events returns the dispatcher of model events, shared with transaction-scoped ORMs
+func (o *ORMImpl) Events() *events.Dispatcher {
+	return o.events
+}
+
+// Context returns the context set by WithContext or the transaction, or
+// context.Background()
+func (o *ORMImpl) Context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// InTransaction reports whether the ORM is bound to a transaction
+func (o *ORMImpl) InTransaction() bool {
+	return o.tx != nil
+}
+
+// ReadOnly reports whether the ORM is bound to a read-only transaction
+func (o *ORMImpl) ReadOnly() bool {
+	td, ok := o.Dialect.(*TransactionDialect)
+	return ok && td.readOnly
+}
+
+// AfterCommit registers fn to run once the outermost transaction commits; it is dropped
+// if the transaction, or the savepoint it was registered in, rolls back. Outside a
+// transaction fn runs immediately.
+func (o *ORMImpl) AfterCommit(fn func() error) error {
+	if o.tx == nil {
+		return fn()
+	}
+	o.tx.mu.Lock()
+	defer o.tx.mu.Unlock()
+	o.tx.afterCommit = append(o.tx.afterCommit, fn)
+	return nil
+}
+
+// AfterRollback registers fn to run if the transaction, or the savepoint it was registered
+// in, rolls back. Outside a transaction there is nothing to roll back and fn never runs.
+func (o *ORMImpl) AfterRollback(fn func() error) error {
+	if o.tx == nil {
+		return nil
+	}
+	o.tx.mu.Lock()
+	defer o.tx.mu.Unlock()
+	o.tx.afterRollback = append(o.tx.afterRollback, fn)
+	return nil
+}
+
+// committed runs the after-commit callbacks in registration order
+func (s *txState) committed() error {
+	s.mu.Lock()
+	callbacks := s.afterCommit
+	s.afterCommit, s.afterRollback = nil, nil
+	s.mu.Unlock()
+	return runCallbacks(callbacks)
+}
+
+// rolledBack drops the after-commit callbacks and runs the after-rollback ones
+func (s *txState) rolledBack() error {
+	s.mu.Lock()
+	callbacks := s.afterRollback
+	s.afterCommit, s.afterRollback = nil, nil
+	s.mu.Unlock()
+	return runCallbacks(callbacks)
+}
+
+// mergeInto hands the tables and callbacks of a released savepoint to its parent
+func (s *txState) mergeInto(parent *txState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+
+	for table := range s.tables {
+		parent.tables[table] = struct{}{}
+	}
+	parent.afterCommit = append(parent.afterCommit, s.afterCommit...)
+	parent.afterRollback = append(parent.afterRollback, s.afterRollback...)
+}
+
+// runCallbacks runs every callback, even after a failure, and joins their errors
+func runCallbacks(callbacks []func() error) error {
+	var errs []error
+	for _, callback := range callbacks {
+		if err := callback(); err != nil {
+			errs = append(errs, err)
 		}
-		return err
 	}
+	return errors.Join(errs...)
+}
 
-	return tx.Commit()
+// touchedTables returns the tables written during the transaction
+func (s *txState) touchedTables() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables := make([]string, 0, len(s.tables))
+	for table := range s.tables {
+		tables = append(tables, table)
+	}
+	return tables
 }
 
 // CreateTable creates a table for the given model
@@ -233,16 +697,197 @@ func (o *ORMImpl) Migrate() error {
 	return nil
 }
 
+// WithCache enables result caching for every query builder, using an in-memory
+// LRU cache unless a custom backend was set with SetCache
+func (o *ORMImpl) WithCache(ttl int) interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cache == nil {
+		o.cache = cache.NewLRU(cache.DefaultCapacity)
+	}
+	o.cacheTTL = ttl
+	return o
+}
+
+// SetCache plugs a custom cache backend into the ORM
+func (o *ORMImpl) SetCache(c interfaces.Cache) interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.cache = c
+	return o
+}
+
+// GetCache returns the cache backend, or nil when caching is disabled
+func (o *ORMImpl) GetCache() interfaces.Cache {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.cache
+}
+
+// InvalidateCache drops cached queries touching the given tables
+func (o *ORMImpl) InvalidateCache(tables ...string) error {
+	if o.tx != nil {
+		o.tx.mu.Lock()
+		for _, table := range tables {
+			o.tx.tables[table] = struct{}{}
+		}
+		o.tx.mu.Unlock()
+	}
+
+	return cache.Invalidate(o.GetCache(), tables...)
+}
+
+// defaultCacheTTL returns the TTL applied to new query builders
+func (o *ORMImpl) defaultCacheTTL() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.cache == nil || o.tx != nil {
+		return 0
+	}
+	return o.cacheTTL
+}
+
+// EnableRequestCoalescing collapses identical concurrent reads into a single database call
+func (o *ORMImpl) EnableRequestCoalescing() interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.coalescer == nil {
+		o.coalescer = &singleflight.Group{}
+	}
+	return o
+}
+
+// DisableRequestCoalescing sends every read to the database again
+func (o *ORMImpl) DisableRequestCoalescing() interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.coalescer = nil
+	return o
+}
+
+// CoalescingStats returns how many reads were issued and how many were deduplicated
+func (o *ORMImpl) CoalescingStats() interfaces.CoalescingStats {
+	group := o.Coalescer()
+	if group == nil {
+		return interfaces.CoalescingStats{}
+	}
+
+	stats := group.Stats()
+	return interfaces.CoalescingStats{
+		Calls:        stats.Calls,
+		Executions:   stats.Executions,
+		Deduplicated: stats.Deduplicated,
+	}
+}
+
+// Coalescer returns the group used to collapse identical reads, or nil when disabled.
+// Transactions never coalesce since their reads must see their own writes.
+func (o *ORMImpl) Coalescer() *singleflight.Group {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.tx != nil {
+		return nil
+	}
+	return o.coalescer
+}
+
+// EnableQueryLog records every statement executed through the dialect.
+// Entries are kept in an in-memory ring buffer and sent to the sinks added with AddQueryLogSink.
+func (o *ORMImpl) EnableQueryLog() interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ensureQueryLogger()
+	o.logQueries = true
+	return o
+}
+
+// DisableQueryLog stops recording statements
+func (o *ORMImpl) DisableQueryLog() interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.logQueries = false
+	return o
+}
+
+// AddQueryLogSink sends recorded statements to sink, such as a logging.SlogSink or logging.FileSink
+func (o *ORMImpl) AddQueryLogSink(sink interfaces.QueryLogSink) interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ensureQueryLogger()
+	o.queryLog.AddSink(sink)
+	return o
+}
+
+// SetSlowQueryThreshold flags statements taking at least threshold as slow
+func (o *ORMImpl) SetSlowQueryThreshold(threshold time.Duration) interfaces.ORM {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ensureQueryLogger()
+	o.queryLog.SetSlowThreshold(threshold)
+	return o
+}
+
+// GetQueryLogger returns the in-memory query log, or nil when logging was never enabled
+func (o *ORMImpl) GetQueryLogger() interfaces.QueryLogger {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if o.queryBuffer == nil {
+		return nil
+	}
+	return o.queryBuffer
+}
+
+// ensureQueryLogger creates the query logger on first use; o.mu must be held
+func (o *ORMImpl) ensureQueryLogger() {
+	if o.queryLog != nil {
+		return
+	}
+
+	o.queryBuffer = logging.NewRingBuffer(logging.DefaultBufferSize)
+	o.queryLog = logging.NewLogger(o.queryBuffer)
+	for _, metadata := range o.Models {
+		o.queryLog.AddSensitiveColumns(sensitiveColumns(metadata)...)
+	}
+}
+
+// sensitiveColumns returns the columns whose values must not appear in query logs
+func sensitiveColumns(metadata *interfaces.ModelMetadata) []string {
+	var columns []string
+	for _, column := range metadata.Columns {
+		if column.Sensitive {
+			columns = append(columns, column.Name)
+		}
+	}
+	return columns
+}
+
 // Add stubs for missing ORMImpl methods
-func (o *ORMImpl) DisableQueryLog() interfaces.ORM                        { return o }
-func (o *ORMImpl) EnableQueryLog() interfaces.ORM                         { return o }
-func (o *ORMImpl) WithCache(ttl int) interfaces.ORM                       { return o }
 func (o *ORMImpl) WithConnectionPool(maxOpen, maxIdle int) interfaces.ORM { return o }
 
 // TransactionDialect wraps a transaction to implement the Dialect interface
 type TransactionDialect struct {
 	tx      interfaces.Transaction
 	dialect interfaces.Dialect
+
+	// savepoints numbers the savepoints of the outermost transaction
+	savepoints *atomic.Int64
+	readOnly   bool
+}
+
+// Unwrap returns the dialect the transaction was started from
+func (td *TransactionDialect) Unwrap() interfaces.Dialect {
+	return td.dialect
 }
 
 // Connect is a no-op for transaction dialect
@@ -262,27 +907,139 @@ func (td *TransactionDialect) Ping() error {
 
 // Exec executes a query on the transaction
 func (td *TransactionDialect) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if td.readOnly && isWriteStatement(query) {
+		return nil, interfaces.ErrReadOnlyTransaction
+	}
 	return td.tx.Exec(query, args...)
 }
 
 // Query executes a query on the transaction
 func (td *TransactionDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if td.readOnly && isWriteStatement(query) {
+		return nil, interfaces.ErrReadOnlyTransaction
+	}
 	return td.tx.Query(query, args...)
 }
 
-// QueryRow executes a query on the transaction
+// QueryRow executes a query on the transaction. A write in a read-only transaction
+// returns a row whose Scan fails with ErrReadOnlyTransaction.
 func (td *TransactionDialect) QueryRow(query string, args ...interface{}) *sql.Row {
+	if td.readOnly && isWriteStatement(query) {
+		return refusedDB.QueryRow(query)
+	}
 	return td.tx.QueryRow(query, args...)
 }
 
-// Begin is not supported for transaction dialect
+// Begin starts a nested transaction as a savepoint of the enclosing transaction
 func (td *TransactionDialect) Begin() (interfaces.Transaction, error) {
-	return nil, fmt.Errorf("nested transactions not supported")
+	return td.begin(context.Background())
 }
 
-// BeginTx is not supported for transaction dialect
+func (td *TransactionDialect) begin(ctx context.Context) (interfaces.Transaction, error) {
+	if td.savepoints == nil {
+		td.savepoints = new(atomic.Int64)
+	}
+	name := fmt.Sprintf("sp_%d", td.savepoints.Add(1))
+	if err := execUnprepared(ctx, td.tx, "SAVEPOINT "+name); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint %s: %w", name, err)
+	}
+	return &savepoint{tx: td.tx, name: name}, nil
+}
+
+// BeginTx starts a nested transaction as a savepoint. The savepoint inherits the
+// enclosing transaction's options, so only default options are accepted.
 func (td *TransactionDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
-	return nil, fmt.Errorf("nested transactions not supported")
+	if opts != nil && (opts.Isolation != sql.LevelDefault || opts.ReadOnly && !td.readOnly) {
+		return nil, fmt.Errorf("nested transactions cannot change transaction options")
+	}
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		ctx = context.Background()
+	}
+	return td.begin(ctx)
+}
+
+// writeStatements are the leading keywords of statements a read-only transaction rejects
+var writeStatements = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true,
+	"CREATE": true, "DROP": true, "ALTER": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true,
+}
+
+// isWriteStatement reports whether query modifies data or schema, judging by its first keyword
+func isWriteStatement(query string) bool {
+	fields := strings.Fields(strings.TrimLeft(query, " \t\r\n("))
+	if len(fields) == 0 {
+		return false
+	}
+	return writeStatements[strings.ToUpper(fields[0])]
+}
+
+// refusedDB is a connection pool failing every statement with ErrReadOnlyTransaction.
+// *sql.Row cannot be built with an error, so QueryRow reports refused writes through it.
+var refusedDB = sql.OpenDB(refusingConnector{})
+
+type refusingConnector struct{}
+
+func (refusingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, interfaces.ErrReadOnlyTransaction
+}
+
+func (refusingConnector) Driver() driver.Driver { return refusingDriver{} }
+
+type refusingDriver struct{}
+
+func (refusingDriver) Open(string) (driver.Conn, error) {
+	return nil, interfaces.ErrReadOnlyTransaction
+}
+
+// execUnprepared runs a savepoint statement directly on tx. Savepoint names are unique
+// per transaction, so preparing them would only fill the statement cache.
+func execUnprepared(ctx context.Context, tx interfaces.Transaction, query string) error {
+	if raw, ok := tx.(interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	}); ok {
+		_, err := raw.ExecContext(ctx, query)
+		return err
+	}
+	_, err := tx.Exec(query)
+	return err
+}
+
+// savepoint is a nested transaction: committing releases the savepoint and rolling
+// back undoes the statements run since it was created
+type savepoint struct {
+	tx   interfaces.Transaction
+	name string
+}
+
+func (s *savepoint) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.tx.Exec(query, args...)
+}
+
+func (s *savepoint) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.tx.Query(query, args...)
+}
+
+func (s *savepoint) QueryRow(query string, args ...interface{}) *sql.Row {
+	return s.tx.QueryRow(query, args...)
+}
+
+func (s *savepoint) Commit() error {
+	if err := execUnprepared(context.Background(), s.tx, "RELEASE SAVEPOINT "+s.name); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", s.name, err)
+	}
+	return nil
+}
+
+func (s *savepoint) Rollback() error {
+	if err := execUnprepared(context.Background(), s.tx, "ROLLBACK TO SAVEPOINT "+s.name); err != nil {
+		return fmt.Errorf("failed to roll back to savepoint %s: %w", s.name, err)
+	}
+	return nil
 }
 
 // CreateTable is not supported for transaction dialect
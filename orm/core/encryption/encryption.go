@@ -0,0 +1,303 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Prefix marks encrypted values; it is followed by the key ID and the base64 encoded nonce
+// and ciphertext: "enc:v1:<key id>:<payload>"
+const Prefix = "enc:v1:"
+
+// ErrNoKeyProvider is returned when encrypted columns are used before SetKeyProvider
+var ErrNoKeyProvider = errors.New("no encryption key provider configured")
+
+// KeyProvider supplies the keys encrypted columns use
+type KeyProvider interface {
+	// CurrentKey returns the ID and key new values are encrypted with
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given ID, to decrypt values written before a rotation
+	Key(id string) ([]byte, error)
+	// IndexKey returns the key blind indexes are computed with. It must not change when
+	// encryption keys rotate, or existing indexes no longer match.
+	IndexKey() ([]byte, error)
+}
+
+var (
+	mu       sync.RWMutex
+	provider KeyProvider
+)
+
+// SetKeyProvider sets the key provider used by encrypted columns
+func SetKeyProvider(p KeyProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	provider = p
+}
+
+// currentProvider returns the configured key provider
+func currentProvider() (KeyProvider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	return provider, nil
+}
+
+// KeyRing is an in-memory KeyProvider. The last key added is the current one, so rotating
+// is adding a new key while keeping the old ones to read existing values.
+type KeyRing struct {
+	mu       sync.RWMutex
+	current  string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// NewKeyRing creates a key ring computing blind indexes with indexKey
+func NewKeyRing(indexKey []byte) *KeyRing {
+	return &KeyRing{keys: make(map[string][]byte), indexKey: indexKey}
+}
+
+// AddKey adds an AES-128, AES-192 or AES-256 key and makes it current
+func (k *KeyRing) AddKey(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("invalid key ID %q", id)
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid key %s: %w", id, err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+	k.current = id
+	return nil
+}
+
+func (k *KeyRing) CurrentKey() (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.current == "" {
+		return "", nil, fmt.Errorf("key ring has no key")
+	}
+	return k.current, k.keys[k.current], nil
+}
+
+func (k *KeyRing) Key(id string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+	return key, nil
+}
+
+func (k *KeyRing) IndexKey() ([]byte, error) {
+	if len(k.indexKey) == 0 {
+		return nil, fmt.Errorf("key ring has no index key")
+	}
+	return k.indexKey, nil
+}
+
+// AAD returns the additional data binding a ciphertext to its column, so a value copied to
+// another column or table fails to decrypt
+func AAD(table, column string) string {
+	return table + "." + column
+}
+
+// Encrypt encrypts plaintext with the current key using AES-GCM
+func Encrypt(plaintext []byte, aad string) (string, error) {
+	p, err := currentProvider()
+	if err != nil {
+		return "", err
+	}
+	id, key, err := p.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(aad))
+	return Prefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt with the key it names
+func Decrypt(ciphertext string, aad string) ([]byte, error) {
+	id, payload, err := split(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	p, err := currentProvider()
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(aad))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %s: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// KeyID returns the ID of the key a value was encrypted with
+func KeyID(ciphertext string) (string, error) {
+	id, _, err := split(ciphertext)
+	return id, err
+}
+
+// Rotate re-encrypts a value with the current key, reporting whether it changed. Values
+// already under the current key are returned as is.
+func Rotate(ciphertext string, aad string) (string, bool, error) {
+	id, err := KeyID(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	p, err := currentProvider()
+	if err != nil {
+		return "", false, err
+	}
+	current, _, err := p.CurrentKey()
+	if err != nil {
+		return "", false, err
+	}
+	if id == current {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := Decrypt(ciphertext, aad)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := Encrypt(plaintext, aad)
+	return rotated, err == nil, err
+}
+
+// BlindIndex returns a keyed hash of plaintext, letting equality lookups match encrypted
+// values without decrypting them
+func BlindIndex(plaintext []byte, aad string) (string, error) {
+	p, err := currentProvider()
+	if err != nil {
+		return "", err
+	}
+	key, err := p.IndexKey()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(aad))
+	mac.Write([]byte{0})
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// EncryptValue encrypts a column value, which must be a string or bytes. nil stays nil.
+func EncryptValue(value interface{}, aad string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plaintext, err := plaintextOf(value)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(plaintext, aad)
+}
+
+// DecryptValue decrypts a stored column value into a string. Values without the encryption
+// prefix, such as rows written before the column was encrypted, are returned as text.
+func DecryptValue(value interface{}, aad string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	stored, err := plaintextOf(value)
+	if err != nil {
+		return nil, err
+	}
+	if !IsEncrypted(string(stored)) {
+		return string(stored), nil
+	}
+	plaintext, err := Decrypt(string(stored), aad)
+	if err != nil {
+		return nil, err
+	}
+	return string(plaintext), nil
+}
+
+// IndexValue returns the blind index of a column value. nil stays nil.
+func IndexValue(value interface{}, aad string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	plaintext, err := plaintextOf(value)
+	if err != nil {
+		return nil, err
+	}
+	return BlindIndex(plaintext, aad)
+}
+
+// plaintextOf returns the bytes of a string or byte slice value
+func plaintextOf(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	return nil, fmt.Errorf("encrypted columns hold strings or bytes, got %T", value)
+}
+
+// split parses an encrypted value into its key ID and payload
+func split(ciphertext string) (string, string, error) {
+	if !IsEncrypted(ciphertext) {
+		return "", "", fmt.Errorf("value is not encrypted")
+	}
+	id, payload, ok := strings.Cut(strings.TrimPrefix(ciphertext, Prefix), ":")
+	if !ok || id == "" {
+		return "", "", fmt.Errorf("malformed encrypted value")
+	}
+	return id, payload, nil
+}
+
+// newGCM returns an AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
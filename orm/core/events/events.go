@@ -0,0 +1,344 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Model event types
+const (
+	Created  = "created"
+	Updated  = "updated"
+	Deleted  = "deleted"
+	Restored = "restored"
+
+	// All subscribes a listener to every event type
+	All = "*"
+)
+
+// DefaultWorkers is the number of workers delivering asynchronous events
+const DefaultWorkers = 4
+
+// Event describes a change persisted through a repository
+type Event struct {
+	Type    string
+	Table   string
+	ID      interface{}
+	Entity  interface{}
+	Context context.Context
+}
+
+// Listener handles an event. Synchronous listeners returning an error fail the operation,
+// rolling back the transaction it runs in.
+type Listener func(Event) error
+
+// subscription is a listener for one model, or every model when model is nil
+type subscription struct {
+	model    reflect.Type
+	event    string
+	listener Listener
+}
+
+func (s subscription) matches(event Event) bool {
+	if s.event != All && s.event != event.Type {
+		return false
+	}
+	return s.model == nil || s.model == modelType(event.Entity)
+}
+
+// Dispatcher delivers model events to synchronous listeners, run inside the operation's
+// transaction, and asynchronous listeners, run by a worker pool once the transaction
+// commits. Events of the same entity are delivered to asynchronous listeners in order.
+type Dispatcher struct {
+	mu      sync.RWMutex
+	sync    []subscription
+	async   []subscription
+	onError func(Event, error)
+
+	// queues are guarded by their own lock, held while pushing so Close cannot close a
+	// queue under a sender
+	qmu     sync.RWMutex
+	workers int
+	queues  []*queue
+
+	// pending counts queued events not delivered yet, including those listeners dispatch
+	pmu     sync.Mutex
+	pending int
+	idle    *sync.Cond
+}
+
+// NewDispatcher creates a dispatcher delivering asynchronous events with the given number
+// of workers, DefaultWorkers when not positive. Workers start with the first event.
+func NewDispatcher(workers int) *Dispatcher {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	d := &Dispatcher{workers: workers}
+	d.idle = sync.NewCond(&d.pmu)
+	return d
+}
+
+// Listen subscribes a synchronous listener to an event type, or All, of a model, or of
+// every model when model is nil
+func (d *Dispatcher) Listen(model interface{}, event string, listener Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sync = append(d.sync, subscription{model: modelType(model), event: event, listener: listener})
+}
+
+// ListenAsync subscribes an asynchronous listener, delivered after commit, to an event
+// type, or All, of a model, or of every model when model is nil
+func (d *Dispatcher) ListenAsync(model interface{}, event string, listener Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.async = append(d.async, subscription{model: modelType(model), event: event, listener: listener})
+}
+
+// HasSyncListeners reports whether a synchronous listener is subscribed to an event of model
+func (d *Dispatcher) HasSyncListeners(model interface{}) bool {
+	t := modelType(model)
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, s := range d.sync {
+		if s.model == nil || s.model == t {
+			return true
+		}
+	}
+	return false
+}
+
+// OnError sets the handler of asynchronous listener errors and panics, which are logged
+// by default
+func (d *Dispatcher) OnError(handler func(Event, error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onError = handler
+}
+
+// Dispatch runs the synchronous listeners of event and queues its asynchronous listeners
+// to run once orm's transaction commits, or immediately outside a transaction. A rolled
+// back transaction delivers nothing to asynchronous listeners.
+func (d *Dispatcher) Dispatch(orm interfaces.ORM, event Event) error {
+	d.mu.RLock()
+	syncListeners := matching(d.sync, event)
+	hasAsync := len(matching(d.async, event)) > 0
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, listener := range syncListeners {
+		if err := call(listener, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s listener failed: %w", event.Type, err)
+	}
+
+	if !hasAsync {
+		return nil
+	}
+	// Listeners run later, so they get a copy of the entity as it was when the event fired
+	event.Entity = snapshot(event.Entity)
+	return orm.AfterCommit(func() error {
+		d.enqueue(event)
+		return nil
+	})
+}
+
+// Close waits for queued asynchronous events to be delivered, along with the events their
+// listeners dispatch, and stops the workers. Later events start them again.
+func (d *Dispatcher) Close() {
+	d.pmu.Lock()
+	for d.pending > 0 {
+		d.idle.Wait()
+	}
+	d.pmu.Unlock()
+
+	d.qmu.Lock()
+	queues := d.queues
+	d.queues = nil
+	d.qmu.Unlock()
+
+	for _, q := range queues {
+		q.close()
+	}
+	for _, q := range queues {
+		<-q.done
+	}
+}
+
+// enqueue hands event to the worker owning its entity, starting the workers if needed.
+// It never blocks, so listeners can save entities whose events go to their own worker.
+func (d *Dispatcher) enqueue(event Event) {
+	for {
+		d.qmu.RLock()
+		if d.queues != nil {
+			d.pmu.Lock()
+			d.pending++
+			d.pmu.Unlock()
+			d.queues[partition(event, len(d.queues))].push(event)
+			d.qmu.RUnlock()
+			return
+		}
+		d.qmu.RUnlock()
+
+		d.qmu.Lock()
+		if d.queues == nil {
+			d.queues = make([]*queue, d.workers)
+			for i := range d.queues {
+				d.queues[i] = newQueue()
+				go d.work(d.queues[i])
+			}
+		}
+		d.qmu.Unlock()
+	}
+}
+
+// work delivers the events of one queue in order
+func (d *Dispatcher) work(q *queue) {
+	defer close(q.done)
+	for {
+		pending, ok := q.next()
+		if !ok {
+			return
+		}
+		for _, event := range pending {
+			d.deliver(event)
+
+			d.pmu.Lock()
+			if d.pending--; d.pending == 0 {
+				d.idle.Broadcast()
+			}
+			d.pmu.Unlock()
+		}
+	}
+}
+
+// deliver runs the asynchronous listeners of event, reporting their errors
+func (d *Dispatcher) deliver(event Event) {
+	d.mu.RLock()
+	listeners := matching(d.async, event)
+	onError := d.onError
+	d.mu.RUnlock()
+
+	for _, listener := range listeners {
+		if err := call(listener, event); err != nil {
+			if onError == nil {
+				log.Printf("orm: async %s listener on %s %v failed: %v", event.Type, event.Table, event.ID, err)
+				continue
+			}
+			onError(event, err)
+		}
+	}
+}
+
+// queue is the unbounded FIFO of a worker's events
+type queue struct {
+	mu     sync.Mutex
+	events []Event
+	closed bool
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+func (q *queue) push(event Event) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for events and takes them all; ok is false once the queue is closed and drained
+func (q *queue) next() (events []Event, ok bool) {
+	for {
+		q.mu.Lock()
+		events, closed := q.events, q.closed
+		q.events = nil
+		q.mu.Unlock()
+
+		if len(events) > 0 {
+			return events, true
+		}
+		if closed {
+			return nil, false
+		}
+		<-q.wake
+	}
+}
+
+// call runs a listener, turning a panic into an error
+func call(listener Listener, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("listener panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return listener(event)
+}
+
+// matching returns the listeners of subscriptions matching event
+func matching(subscriptions []subscription, event Event) []Listener {
+	var listeners []Listener
+	for _, s := range subscriptions {
+		if s.matches(event) {
+			listeners = append(listeners, s.listener)
+		}
+	}
+	return listeners
+}
+
+// partition picks the worker of an entity, so its events are delivered in order
+func partition(event Event, workers int) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%v", event.Table, event.ID)
+	return int(h.Sum32() % uint32(workers))
+}
+
+// modelType returns the struct type of a model or entity, nil for nil
+func modelType(model interface{}) reflect.Type {
+	if model == nil {
+		return nil
+	}
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// snapshot returns a pointer to a shallow copy of a struct entity
+func snapshot(entity interface{}) interface{} {
+	value := reflect.ValueOf(entity)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return entity
+	}
+	copied := reflect.New(value.Elem().Type())
+	copied.Elem().Set(value.Elem())
+	return copied.Interface()
+}
@@ -0,0 +1,174 @@
+package interceptor
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Chain runs stmt through the interceptors, in order, before reaching final
+func Chain(ctx context.Context, stmt interfaces.Statement, interceptors []interfaces.Interceptor, final interfaces.StatementHandler) (interfaces.StatementResult, error) {
+	handler := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		current, next := interceptors[i], handler
+		handler = func(ctx context.Context, stmt interfaces.Statement) (interfaces.StatementResult, error) {
+			return current(ctx, stmt, next)
+		}
+	}
+	return handler(ctx, stmt)
+}
+
+// Dialect wraps a dialect so that every statement, and every transaction it starts,
+// goes through the interceptor chain
+type Dialect struct {
+	interfaces.Dialect
+	ctx          context.Context
+	interceptors []interfaces.Interceptor
+}
+
+// NewDialect wraps dialect with the given interceptors, passing ctx to calls that carry no context
+func NewDialect(ctx context.Context, dialect interfaces.Dialect, interceptors []interfaces.Interceptor) *Dialect {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Dialect{Dialect: dialect, ctx: ctx, interceptors: interceptors}
+}
+
+// Unwrap returns the wrapped dialect
+func (d *Dialect) Unwrap() interfaces.Dialect {
+	return d.Dialect
+}
+
+// Exec executes a statement through the chain
+func (d *Dialect) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return exec(d.ctx, d.interceptors, false, query, args, d.Dialect.Exec)
+}
+
+// Query executes a query through the chain
+func (d *Dialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return queryRows(d.ctx, d.interceptors, false, query, args, d.Dialect.Query)
+}
+
+// QueryRow executes a single-row query through the chain
+func (d *Dialect) QueryRow(query string, args ...interface{}) *sql.Row {
+	return queryRow(d.ctx, d.interceptors, false, query, args, d.Dialect.QueryRow)
+}
+
+// Begin starts a transaction through the chain
+func (d *Dialect) Begin() (interfaces.Transaction, error) {
+	return d.begin(d.ctx, interfaces.Statement{Kind: interfaces.StatementBegin}, func(context.Context, *sql.TxOptions) (interfaces.Transaction, error) {
+		return d.Dialect.Begin()
+	})
+}
+
+// BeginTx starts a transaction with options through the chain
+func (d *Dialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
+	return d.begin(ctx, interfaces.Statement{Kind: interfaces.StatementBegin, TxOptions: opts}, d.Dialect.BeginTx)
+}
+
+// begin runs a begin statement and wraps the resulting transaction
+func (d *Dialect) begin(ctx context.Context, stmt interfaces.Statement, start func(context.Context, *sql.TxOptions) (interfaces.Transaction, error)) (interfaces.Transaction, error) {
+	result, err := Chain(ctx, stmt, d.interceptors, func(ctx context.Context, stmt interfaces.Statement) (interfaces.StatementResult, error) {
+		tx, err := start(ctx, stmt.TxOptions)
+		return interfaces.StatementResult{Tx: tx}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{tx: result.Tx, ctx: ctx, interceptors: d.interceptors}, nil
+}
+
+// Transaction wraps a transaction so that its statements, commit and rollback go through the chain
+type Transaction struct {
+	tx           interfaces.Transaction
+	ctx          context.Context
+	interceptors []interfaces.Interceptor
+}
+
+// Unwrap returns the wrapped transaction
+func (t *Transaction) Unwrap() interfaces.Transaction {
+	return t.tx
+}
+
+// Commit commits the transaction through the chain
+func (t *Transaction) Commit() error {
+	return t.settle(interfaces.StatementCommit, t.tx.Commit)
+}
+
+// Rollback rolls the transaction back through the chain
+func (t *Transaction) Rollback() error {
+	return t.settle(interfaces.StatementRollback, t.tx.Rollback)
+}
+
+// Exec executes a statement in the transaction through the chain
+func (t *Transaction) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return exec(t.ctx, t.interceptors, true, query, args, t.tx.Exec)
+}
+
+// ExecContext executes a statement in the transaction through the chain, bypassing the
+// wrapped transaction's statement cache when it has one
+func (t *Transaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	raw, ok := t.tx.(interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	})
+	if !ok {
+		return t.Exec(query, args...)
+	}
+	return exec(t.ctx, t.interceptors, true, query, args, func(query string, args ...interface{}) (sql.Result, error) {
+		return raw.ExecContext(ctx, query, args...)
+	})
+}
+
+// Query executes a query in the transaction through the chain
+func (t *Transaction) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return queryRows(t.ctx, t.interceptors, true, query, args, t.tx.Query)
+}
+
+// QueryRow executes a single-row query in the transaction through the chain
+func (t *Transaction) QueryRow(query string, args ...interface{}) *sql.Row {
+	return queryRow(t.ctx, t.interceptors, true, query, args, t.tx.QueryRow)
+}
+
+// settle runs a commit or rollback through the chain
+func (t *Transaction) settle(kind interfaces.StatementKind, fn func() error) error {
+	stmt := interfaces.Statement{Kind: kind, InTransaction: true}
+	_, err := Chain(t.ctx, stmt, t.interceptors, func(context.Context, interfaces.Statement) (interfaces.StatementResult, error) {
+		return interfaces.StatementResult{}, fn()
+	})
+	return err
+}
+
+// exec runs an Exec statement through the chain
+func exec(ctx context.Context, interceptors []interfaces.Interceptor, inTx bool, query string, args []interface{}, fn func(string, ...interface{}) (sql.Result, error)) (sql.Result, error) {
+	stmt := interfaces.Statement{Kind: interfaces.StatementExec, SQL: query, Args: args, InTransaction: inTx}
+	result, err := Chain(ctx, stmt, interceptors, func(_ context.Context, stmt interfaces.Statement) (interfaces.StatementResult, error) {
+		res, err := fn(stmt.SQL, stmt.Args...)
+		return interfaces.StatementResult{Result: res}, err
+	})
+	return result.Result, err
+}
+
+// queryRows runs a Query statement through the chain
+func queryRows(ctx context.Context, interceptors []interfaces.Interceptor, inTx bool, query string, args []interface{}, fn func(string, ...interface{}) (*sql.Rows, error)) (*sql.Rows, error) {
+	stmt := interfaces.Statement{Kind: interfaces.StatementQuery, SQL: query, Args: args, InTransaction: inTx}
+	result, err := Chain(ctx, stmt, interceptors, func(_ context.Context, stmt interfaces.Statement) (interfaces.StatementResult, error) {
+		rows, err := fn(stmt.SQL, stmt.Args...)
+		return interfaces.StatementResult{Rows: rows}, err
+	})
+	return result.Rows, err
+}
+
+// queryRow runs a QueryRow statement through the chain; errors surface when the row is scanned.
+// The row is nil when an interceptor answers without reaching the database.
+func queryRow(ctx context.Context, interceptors []interfaces.Interceptor, inTx bool, query string, args []interface{}, fn func(string, ...interface{}) *sql.Row) *sql.Row {
+	stmt := interfaces.Statement{Kind: interfaces.StatementQueryRow, SQL: query, Args: args, InTransaction: inTx}
+	result, _ := Chain(ctx, stmt, interceptors, func(_ context.Context, stmt interfaces.Statement) (interfaces.StatementResult, error) {
+		row := fn(stmt.SQL, stmt.Args...)
+		if row == nil {
+			return interfaces.StatementResult{}, nil
+		}
+		return interfaces.StatementResult{Row: row}, row.Err()
+	})
+	return result.Row
+}
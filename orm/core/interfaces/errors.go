@@ -0,0 +1,142 @@
+package interfaces
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned by the ORM. Dialects wrap driver errors with them, keeping the
+// driver error in the chain, so callers can branch with errors.Is.
+var (
+	// ErrNotFound is returned when a lookup matches no record
+	ErrNotFound = errors.New("record not found")
+	// ErrDeadlock is returned when the database aborted a transaction to resolve a deadlock,
+	// lock wait timeout or serialization conflict; running it again may succeed
+	ErrDeadlock = errors.New("transaction aborted by a deadlock or serialization conflict")
+	// ErrConnection is returned when the database cannot be reached or dropped the connection
+	ErrConnection = errors.New("database connection failed")
+	// ErrReadOnlyTransaction is returned when a write is attempted in a read-only transaction
+	ErrReadOnlyTransaction = errors.New("write statement in a read-only transaction")
+	// ErrNoTenant is returned when a tenant-scoped model is used without a tenant in the
+	// context, unless the context bypasses tenant scoping
+	ErrNoTenant = errors.New("no tenant in context")
+)
+
+// ErrUniqueViolation is returned when a write breaks a unique constraint
+type ErrUniqueViolation struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ErrUniqueViolation) Error() string {
+	return constraintMessage("unique constraint violated", e.Constraint, e.Err)
+}
+
+func (e *ErrUniqueViolation) Unwrap() error { return e.Err }
+
+// Is matches any unique violation when target has no constraint, or the same constraint otherwise
+func (e *ErrUniqueViolation) Is(target error) bool {
+	t, ok := target.(*ErrUniqueViolation)
+	return ok && (t.Constraint == "" || t.Constraint == e.Constraint)
+}
+
+// ErrForeignKeyViolation is returned when a write references a missing row or deletes a referenced one
+type ErrForeignKeyViolation struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ErrForeignKeyViolation) Error() string {
+	return constraintMessage("foreign key constraint violated", e.Constraint, e.Err)
+}
+
+func (e *ErrForeignKeyViolation) Unwrap() error { return e.Err }
+
+// Is matches any foreign key violation when target has no constraint, or the same constraint otherwise
+func (e *ErrForeignKeyViolation) Is(target error) bool {
+	t, ok := target.(*ErrForeignKeyViolation)
+	return ok && (t.Constraint == "" || t.Constraint == e.Constraint)
+}
+
+// ErrNotNullViolation is returned when a write leaves a NOT NULL column empty
+type ErrNotNullViolation struct {
+	Column string
+	Err    error
+}
+
+func (e *ErrNotNullViolation) Error() string {
+	return constraintMessage("not null constraint violated", e.Column, e.Err)
+}
+
+func (e *ErrNotNullViolation) Unwrap() error { return e.Err }
+
+// Is matches any not null violation when target has no column, or the same column otherwise
+func (e *ErrNotNullViolation) Is(target error) bool {
+	t, ok := target.(*ErrNotNullViolation)
+	return ok && (t.Column == "" || t.Column == e.Column)
+}
+
+// ErrStaleObject is returned when an update or delete guarded by a version column matched
+// no row: another writer changed or deleted the record since it was loaded
+type ErrStaleObject struct {
+	Table   string
+	ID      interface{}
+	Version int64
+}
+
+func (e *ErrStaleObject) Error() string {
+	return fmt.Sprintf("stale object: %s %v was modified since version %d", e.Table, e.ID, e.Version)
+}
+
+// Is matches any stale object when target has no table, or the same table otherwise
+func (e *ErrStaleObject) Is(target error) bool {
+	t, ok := target.(*ErrStaleObject)
+	return ok && (t.Table == "" || t.Table == e.Table)
+}
+
+// ErrMassAssignment is returned when Fill is given keys that are protected or match no column
+type ErrMassAssignment struct {
+	Table string
+	Keys  []string
+}
+
+func (e *ErrMassAssignment) Error() string {
+	return fmt.Sprintf("mass assignment rejected for %s: %s", e.Table, strings.Join(e.Keys, ", "))
+}
+
+// Is matches any mass assignment error when target has no table, or the same table otherwise
+func (e *ErrMassAssignment) Is(target error) bool {
+	t, ok := target.(*ErrMassAssignment)
+	return ok && (t.Table == "" || t.Table == e.Table)
+}
+
+// FieldError describes a column failing a validation rule
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationErrors lists the validation rules an entity failed. It is returned before any
+// statement is sent to the database.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// constraintMessage formats a constraint error with its optional name and cause
+func constraintMessage(message, name string, err error) string {
+	if name != "" {
+		message += " on " + name
+	}
+	if err != nil {
+		message += ": " + err.Error()
+	}
+	return message
+}
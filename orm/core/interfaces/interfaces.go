@@ -20,6 +20,12 @@ type ORM interface {
 	Repository(model interface{}) Repository
 	Transaction(fn func(ORM) error) error
 	TransactionWithContext(ctx context.Context, fn func(ORM) error) error
+	TransactionWithOptions(ctx context.Context, opts TxOptions, fn func(ORM) error) error
+	TransactionWithRetry(opts RetryOptions, fn func(ORM) error) error
+	AfterCommit(fn func() error) error
+	AfterRollback(fn func() error) error
+	WithContext(ctx context.Context) ORM
+	Context() context.Context
 	CreateTable(model interface{}) error
 	DropTable(model interface{}) error
 	Migrate() error
@@ -28,6 +34,16 @@ type ORM interface {
 	WithConnectionPool(maxOpen, maxIdle int) ORM
 	EnableQueryLog() ORM
 	DisableQueryLog() ORM
+	SetCache(cache Cache) ORM
+	GetCache() Cache
+	InvalidateCache(tables ...string) error
+	EnableRequestCoalescing() ORM
+	DisableRequestCoalescing() ORM
+	CoalescingStats() CoalescingStats
+	AddQueryLogSink(sink QueryLogSink) ORM
+	SetSlowQueryThreshold(threshold time.Duration) ORM
+	GetQueryLogger() QueryLogger
+	Use(interceptors ...Interceptor) ORM
 }
 
 // Dialect defines the database dialect interface
@@ -52,6 +68,22 @@ type Dialect interface {
 	GetJSONExtract() string
 }
 
+// RetryOptions configures TransactionWithRetry. Zero values fall back to defaults.
+type RetryOptions struct {
+	MaxAttempts int              // total attempts including the first, default 3
+	BaseDelay   time.Duration    // backoff before the first retry, doubled on each retry, default 10ms
+	MaxDelay    time.Duration    // upper bound of the backoff, default 1s
+	Context     context.Context  // cancels the transaction and any pending backoff, default the ORM's context
+	Retryable   func(error) bool // overrides the dialect's classifier of retryable errors
+}
+
+// TxOptions configures TransactionWithOptions. The zero value starts a default transaction.
+type TxOptions struct {
+	Isolation  sql.IsolationLevel // isolation level, the database default when zero
+	ReadOnly   bool               // writes are rejected before reaching the database
+	Deferrable bool               // PostgreSQL only: a serializable read-only transaction waits for a safe snapshot instead of risking serialization failures
+}
+
 // Transaction defines the transaction interface
 type Transaction interface {
 	Commit() error
@@ -109,6 +141,9 @@ type QueryBuilder interface {
 	Lock(lockType string) QueryBuilder
 	Cache(ttl int) QueryBuilder
 	WithoutCache() QueryBuilder
+	WithTrashed() QueryBuilder
+	OnlyTrashed() QueryBuilder
+	UsePrimary() QueryBuilder
 }
 
 // Repository defines the repository interface
@@ -141,6 +176,41 @@ type Repository interface {
 	Value(field string) (interface{}, error)
 	Increment(field string, amount interface{}) error
 	Decrement(field string, amount interface{}) error
+	Load(id interface{}) (interface{}, error)
+	LoadBy(criteria map[string]interface{}) ([]interface{}, error)
+	Changes(entity interface{}) Changes
+	Fill(entity interface{}, data map[string]interface{}) error
+	CreateFrom(data map[string]interface{}) (interface{}, error)
+	Validate(entity interface{}) error
+	History(id interface{}) ([]AuditEntry, error)
+}
+
+// Change is the original and current value of a modified column
+type Change struct {
+	Old interface{}
+	New interface{}
+}
+
+// Changes maps the modified columns of a tracked entity to their change
+type Changes map[string]Change
+
+// IsDirty reports whether the column was modified since the entity was loaded or saved
+func (c Changes) IsDirty(column string) bool {
+	_, ok := c[column]
+	return ok
+}
+
+// AuditEntry is a row of the audit log: one change made to a record of an auditable model.
+// Updates hold the columns they changed, creates only new values and deletes only old ones.
+type AuditEntry struct {
+	ID        int64
+	Table     string
+	RecordID  string
+	Action    string
+	OldValues map[string]interface{}
+	NewValues map[string]interface{}
+	Actor     string
+	CreatedAt time.Time
 }
 
 // ConnectionConfig defines database connection configuration
@@ -158,6 +228,20 @@ type ConnectionConfig struct {
 	QueryTimeout    int // in seconds
 	EnableQueryLog  bool
 	CacheTTL        int // in seconds
+	// StatementCacheSize is the number of prepared statements kept by the MySQL and
+	// PostgreSQL dialects; 0 disables the statement cache
+	StatementCacheSize int
+	Replicas           []ReplicaConfig
+	ReplicaStrategy    string // "round_robin" (default) or "least_latency"
+}
+
+// ReplicaConfig describes a read replica; empty fields inherit the primary's settings
+type ReplicaConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Database string
 }
 
 // Column represents a database column
@@ -177,15 +261,25 @@ type Column struct {
 	Timestamp  bool
 	JSON       bool
 	FullText   bool
-	Encrypted  bool
+	Encrypted  bool   // stored AES-GCM encrypted through the encryption key provider
+	BlindIndex string // column holding a keyed hash of the plaintext for equality lookups
+	Sensitive  bool
+	Version    bool   // optimistic locking counter, checked and incremented on every write
+	Fillable   bool   // may be set by Fill; once a column is fillable, all others are protected
+	Guarded    bool   // never set by Fill
+	Hidden     bool   // left out of serialized output
+	Visible    bool   // once a column is visible, only visible columns are serialized
+	Cast       string // named cast converting the value on hydration, persistence and serialization
+	Tenant     bool   // holds the tenant rows are scoped to through the context
 	Validation []ValidationRule
 }
 
 // ValidationRule represents a validation rule
 type ValidationRule struct {
+	Column  string // column the rule applies to, set in ModelMetadata.Validation
 	Type    string
 	Value   interface{}
-	Message string
+	Message string // replaces the rule's own message when set
 }
 
 // ForeignKey represents a foreign key constraint
@@ -258,6 +352,7 @@ type ModelMetadata struct {
 	CreatedAt   string
 	UpdatedAt   string
 	DeletedAt   string
+	Version     string
 	Hooks       *ModelHooks
 	Scopes      map[string]func(QueryBuilder) QueryBuilder
 	Validation  []ValidationRule
@@ -267,9 +362,16 @@ type ModelMetadata struct {
 	Guarded     []string
 	Appends     []string
 	Casts       map[string]string
+	Encrypted   map[string]string // encrypted columns and their blind index column, if any
+	Auditable   bool
+	Tenant      string // column holding the tenant rows are scoped to, if any
 	Events      map[string][]func(interface{}) error
 }
 
+// HookFunc is a model hook called with the context of the ORM running the operation and
+// that ORM, transaction-scoped inside a transaction. An error aborts the operation.
+type HookFunc func(ctx context.Context, orm ORM, entity interface{}) error
+
 // ModelHooks represents model lifecycle hooks
 type ModelHooks struct {
 	BeforeCreate []func(interface{}) error
@@ -280,6 +382,13 @@ type ModelHooks struct {
 	AfterDelete  []func(interface{}) error
 	BeforeSave   []func(interface{}) error
 	AfterSave    []func(interface{}) error
+	AfterFind    []func(interface{}) error
+	// Methods holds the hook methods discovered on the model at registration, keyed by
+	// name, run after the hook functions of the same name
+	Methods map[string]HookFunc
+	// Deferred holds After hooks run inside a transaction until the outermost commit and
+	// drops them on rollback, for side effects such as emails and published events
+	Deferred bool
 }
 
 // WhereCondition represents a WHERE clause condition
@@ -331,15 +440,73 @@ type Cache interface {
 	Has(key string) bool
 }
 
+// TaggedCache is a Cache able to invalidate groups of entries by tag
+type TaggedCache interface {
+	Cache
+	SetWithTags(key string, value interface{}, ttl int, tags []string) error
+	InvalidateTags(tags ...string) error
+}
+
+// CoalescingStats reports how many identical concurrent reads were collapsed
+type CoalescingStats struct {
+	Calls        int64
+	Executions   int64
+	Deduplicated int64
+}
+
+// StatementKind identifies the database call seen by an interceptor
+type StatementKind string
+
+// Intercepted database calls
+const (
+	StatementExec     StatementKind = "exec"
+	StatementQuery    StatementKind = "query"
+	StatementQueryRow StatementKind = "query_row"
+	StatementBegin    StatementKind = "begin"
+	StatementCommit   StatementKind = "commit"
+	StatementRollback StatementKind = "rollback"
+)
+
+// Statement describes an intercepted database call.
+// Interceptors may rewrite SQL and Args before passing the statement to the next handler.
+type Statement struct {
+	Kind          StatementKind
+	SQL           string
+	Args          []interface{}
+	InTransaction bool
+	TxOptions     *sql.TxOptions
+}
+
+// StatementResult holds the outcome of an intercepted call; only the field matching the statement kind is set
+type StatementResult struct {
+	Result sql.Result
+	Rows   *sql.Rows
+	Row    *sql.Row
+	Tx     Transaction
+}
+
+// StatementHandler executes a statement
+type StatementHandler func(ctx context.Context, stmt Statement) (StatementResult, error)
+
+// Interceptor wraps a database call and must call next to reach the database
+type Interceptor func(ctx context.Context, stmt Statement, next StatementHandler) (StatementResult, error)
+
 // QueryLog represents a query log entry
 type QueryLog struct {
 	SQL      string
 	Args     []interface{}
 	Duration time.Duration
 	Time     time.Time
+	Rows     int64 // rows affected by Exec, -1 when the result set is streamed to the caller
+	Slow     bool
 	Error    error
 }
 
+// QueryLogSink receives every recorded query log entry
+type QueryLogSink interface {
+	Log(log QueryLog)
+}
+
 // QueryLogger interface for query logging
 type QueryLogger interface {
 	Log(log QueryLog)
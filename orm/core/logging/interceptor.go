@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Intercept records Exec, Query and QueryRow statements as they are executed.
+// It is meant to be the last interceptor of the chain so that rewritten SQL is logged.
+func (l *Logger) Intercept(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+	switch stmt.Kind {
+	case interfaces.StatementExec, interfaces.StatementQuery, interfaces.StatementQueryRow:
+	default:
+		return next(ctx, stmt)
+	}
+
+	start := time.Now()
+	result, err := next(ctx, stmt)
+
+	rows := int64(-1)
+	if err == nil && result.Result != nil {
+		if affected, rowsErr := result.Result.RowsAffected(); rowsErr == nil {
+			rows = affected
+		}
+	}
+
+	l.Record(stmt.SQL, stmt.Args, start, rows, err)
+	return result, err
+}
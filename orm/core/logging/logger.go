@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// DefaultBufferSize is the number of entries kept by the ORM's default query log
+const DefaultBufferSize = 1000
+
+// Redacted replaces arguments bound to sensitive columns
+const Redacted = "[REDACTED]"
+
+// Logger records executed statements and forwards them to its sinks.
+// It is safe for concurrent use.
+type Logger struct {
+	mu            sync.RWMutex
+	sinks         []interfaces.QueryLogSink
+	slowThreshold time.Duration
+	sensitive     map[string]struct{}
+}
+
+// NewLogger creates a logger writing to the given sinks
+func NewLogger(sinks ...interfaces.QueryLogSink) *Logger {
+	return &Logger{
+		sinks:     sinks,
+		sensitive: make(map[string]struct{}),
+	}
+}
+
+// AddSink registers an additional sink
+func (l *Logger) AddSink(sink interfaces.QueryLogSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// SetSlowThreshold flags statements taking at least threshold as slow (0 disables it)
+func (l *Logger) SetSlowThreshold(threshold time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.slowThreshold = threshold
+}
+
+// SlowThreshold returns the current slow-query threshold
+func (l *Logger) SlowThreshold() time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.slowThreshold
+}
+
+// AddSensitiveColumns marks columns whose bound arguments must never be logged
+func (l *Logger) AddSensitiveColumns(columns ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, column := range columns {
+		l.sensitive[strings.ToLower(column)] = struct{}{}
+	}
+}
+
+// Record logs a statement that started at start
+func (l *Logger) Record(query string, args []interface{}, start time.Time, rows int64, err error) {
+	duration := time.Since(start)
+
+	l.mu.RLock()
+	sinks := l.sinks
+	threshold := l.slowThreshold
+	redacted := redactArgs(query, args, l.sensitive)
+	l.mu.RUnlock()
+
+	entry := interfaces.QueryLog{
+		SQL:      query,
+		Args:     redacted,
+		Duration: duration,
+		Time:     start,
+		Rows:     rows,
+		Slow:     threshold > 0 && duration >= threshold,
+		Error:    err,
+	}
+
+	for _, sink := range sinks {
+		sink.Log(entry)
+	}
+}
@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token kinds produced by tokenize
+const (
+	tokenWord = iota
+	tokenPlaceholder
+	tokenLiteral
+	tokenPunct
+)
+
+// token is a lexical element of a SQL statement
+type token struct {
+	kind  int
+	text  string
+	index int // argument index for placeholders
+}
+
+// skippedWords may sit between a column and its placeholder, as in "age NOT BETWEEN ? AND ?"
+var skippedWords = map[string]bool{
+	"IN": true, "NOT": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "AND": true, "IS": true,
+}
+
+// clauseWords end the backward search for a column, as in "LIMIT ?"
+var clauseWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "SET": true, "VALUES": true, "ON": true,
+	"HAVING": true, "BY": true, "LIMIT": true, "OFFSET": true, "OR": true, "RETURNING": true,
+}
+
+// redactArgs returns a copy of args where values bound to sensitive columns are replaced
+func redactArgs(query string, args []interface{}, sensitive map[string]struct{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	redacted := make([]interface{}, len(args))
+	copy(redacted, args)
+	if len(sensitive) == 0 {
+		return redacted
+	}
+
+	for i, column := range boundColumns(query, len(args)) {
+		if _, ok := sensitive[column]; ok {
+			redacted[i] = Redacted
+		}
+	}
+	return redacted
+}
+
+// boundColumns guesses, for each of the n arguments of query, the column it is bound to.
+// Unknown bindings are left empty.
+func boundColumns(query string, n int) []string {
+	columns := make([]string, n)
+	tokens := tokenize(query)
+
+	bind := func(tok token, column string) {
+		if tok.index >= 0 && tok.index < n && column != "" {
+			columns[tok.index] = column
+		}
+	}
+
+	// INSERT INTO table (a, b) VALUES (?, ?), (?, ?) binds positionally
+	start := 0
+	if len(tokens) > 0 && strings.EqualFold(tokens[0].text, "INSERT") {
+		start = bindInsertValues(tokens, bind)
+	}
+
+	for i := start; i < len(tokens); i++ {
+		if tokens[i].kind == tokenPlaceholder {
+			bind(tokens[i], columnBefore(tokens, i))
+		}
+	}
+
+	return columns
+}
+
+// bindInsertValues binds the placeholders of an INSERT's VALUES lists to its column list
+// and returns the index of the first token after them
+func bindInsertValues(tokens []token, bind func(token, string)) int {
+	var insertColumns []string
+	i := 0
+	for ; i < len(tokens) && tokens[i].text != "("; i++ {
+	}
+	for i++; i < len(tokens) && tokens[i].text != ")"; i++ {
+		if tokens[i].kind == tokenWord {
+			insertColumns = append(insertColumns, strings.ToLower(tokens[i].text))
+		}
+	}
+	for ; i < len(tokens) && !strings.EqualFold(tokens[i].text, "VALUES"); i++ {
+	}
+	if i >= len(tokens) || len(insertColumns) == 0 {
+		return 0
+	}
+
+	depth, position := 0, 0
+	for i++; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.text == "(":
+			depth++
+			if depth == 1 {
+				position = 0
+			}
+		case tok.text == ")":
+			depth--
+		case depth == 0:
+			if tok.text != "," {
+				return i
+			}
+		case depth == 1 && tok.text == ",":
+			position++
+		case tok.kind == tokenPlaceholder:
+			bind(tok, insertColumns[position%len(insertColumns)])
+		}
+	}
+	return i
+}
+
+// columnBefore walks back from the placeholder at index i to the column it is compared with
+func columnBefore(tokens []token, i int) string {
+	for j := i - 1; j >= 0; j-- {
+		tok := tokens[j]
+		switch tok.kind {
+		case tokenPlaceholder, tokenPunct:
+			continue
+		case tokenLiteral:
+			return ""
+		}
+
+		upper := strings.ToUpper(tok.text)
+		if skippedWords[upper] {
+			continue
+		}
+		if clauseWords[upper] {
+			return ""
+		}
+		return strings.ToLower(tok.text)
+	}
+	return ""
+}
+
+// tokenize splits a SQL statement into words, placeholders, literals and punctuation
+func tokenize(query string) []token {
+	var tokens []token
+	ordinal := 0
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) {
+				if runes[end] == '\'' {
+					if end+1 < len(runes) && runes[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, index: -1})
+			i = end + 1
+		case r == '"' || r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[i+1 : min(end, len(runes))]), index: -1})
+			i = end + 1
+		case r == '?':
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: "?", index: ordinal})
+			ordinal++
+			i++
+		case r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			end := i + 1
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+			position, _ := strconv.Atoi(string(runes[i+1 : end]))
+			tokens = append(tokens, token{kind: tokenPlaceholder, text: string(runes[i:end]), index: position - 1})
+			i = end
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (runes[end] == '_' || runes[end] == '.' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			word := string(runes[i:end])
+			kind := tokenWord
+			if unicode.IsDigit(r) {
+				kind = tokenLiteral
+			} else if dot := strings.LastIndex(word, "."); dot >= 0 {
+				word = word[dot+1:]
+			}
+			tokens = append(tokens, token{kind: kind, text: word, index: -1})
+			i = end
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(r), index: -1})
+			i++
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// RingBuffer keeps the most recent entries in memory.
+// It implements interfaces.QueryLogger.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []interfaces.QueryLog
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a buffer holding at most size entries
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &RingBuffer{entries: make([]interfaces.QueryLog, size)}
+}
+
+// Log stores an entry, overwriting the oldest one when the buffer is full
+func (b *RingBuffer) Log(entry interfaces.QueryLog) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// GetLogs returns the buffered entries, oldest first
+func (b *RingBuffer) GetLogs() []interfaces.QueryLog {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]interfaces.QueryLog(nil), b.entries[:b.next]...)
+	}
+	logs := make([]interfaces.QueryLog, 0, len(b.entries))
+	logs = append(logs, b.entries[b.next:]...)
+	return append(logs, b.entries[:b.next]...)
+}
+
+// ClearLogs drops every buffered entry
+func (b *RingBuffer) ClearLogs() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	clear(b.entries)
+	b.next = 0
+	b.full = false
+}
+
+// SlogSink writes entries to a structured logger: failed queries at error level,
+// slow queries at warn level and everything else at debug level
+type SlogSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogSink creates a sink writing to logger, or to slog.Default() when nil
+func NewSlogSink(logger *slog.Logger) *SlogSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogSink{logger: logger}
+}
+
+// Log writes an entry to the structured logger
+func (s *SlogSink) Log(entry interfaces.QueryLog) {
+	level, message := slog.LevelDebug, "query"
+	switch {
+	case entry.Error != nil:
+		level, message = slog.LevelError, "query failed"
+	case entry.Slow:
+		level, message = slog.LevelWarn, "slow query"
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", entry.SQL),
+		slog.Any("args", entry.Args),
+		slog.Duration("duration", entry.Duration),
+		slog.Int64("rows", entry.Rows),
+	}
+	if entry.Error != nil {
+		attrs = append(attrs, slog.String("error", entry.Error.Error()))
+	}
+
+	s.logger.LogAttrs(context.Background(), level, message, attrs...)
+}
+
+// WriterSink writes entries as JSON lines
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// writerRecord is the JSON representation of an entry
+type writerRecord struct {
+	Time       time.Time     `json:"time"`
+	SQL        string        `json:"sql"`
+	Args       []interface{} `json:"args,omitempty"`
+	DurationMS float64       `json:"duration_ms"`
+	Rows       int64         `json:"rows"`
+	Slow       bool          `json:"slow,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// NewWriterSink creates a sink writing to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Log writes an entry as a single JSON line
+func (s *WriterSink) Log(entry interfaces.QueryLog) {
+	record := writerRecord{
+		Time:       entry.Time,
+		SQL:        entry.SQL,
+		Args:       entry.Args,
+		DurationMS: float64(entry.Duration) / float64(time.Millisecond),
+		Rows:       entry.Rows,
+		Slow:       entry.Slow,
+	}
+	if entry.Error != nil {
+		record.Error = entry.Error.Error()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		// Arguments that cannot be encoded are written in their printed form
+		record.Args = []interface{}{fmt.Sprint(entry.Args...)}
+		if line, err = json.Marshal(record); err != nil {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.Write(append(line, '\n'))
+}
+
+// FileSink appends entries as JSON lines to a file
+type FileSink struct {
+	*WriterSink
+	file *os.File
+}
+
+// NewFileSink opens (or creates) the file at path for appending
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query log file: %w", err)
+	}
+	return &FileSink{WriterSink: NewWriterSink(file), file: file}, nil
+}
+
+// Close closes the underlying file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -1,12 +1,14 @@
 package metadata
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/validation"
 )
 
 // Tag constants for better maintainability
@@ -23,6 +25,8 @@ const (
 	TagLength     = "length"
 	TagDefault    = "default"
 	TagNullable   = "nullable"
+	TagAppends    = "appends"
+	TagValidate   = "validate"
 )
 
 // ORMTag represents parsed ORM tag data
@@ -39,6 +43,18 @@ type ORMTag struct {
 	Relation     string
 	RelationType string
 	SoftDelete   bool
+	Cascade      bool
+	Sensitive    bool
+	Version      bool
+	Fillable     bool
+	Guarded      bool
+	Hidden       bool
+	Visible      bool
+	Cast         string
+	Encrypted    bool
+	BlindIndex   string
+	Auditable    bool
+	Tenant       bool
 }
 
 // extractColumn extracts column information from a struct field
@@ -82,6 +98,21 @@ func (mm *Manager) extractColumn(field reflect.StructField) (*interfaces.Column,
 		column.Unique = ormTag.Unique
 		column.Index = ormTag.Index
 		column.Nullable = ormTag.Nullable
+		column.Sensitive = ormTag.Sensitive
+		column.Version = ormTag.Version
+		column.Fillable = ormTag.Fillable
+		column.Guarded = ormTag.Guarded
+		column.Hidden = ormTag.Hidden
+		column.Visible = ormTag.Visible
+		column.Cast = ormTag.Cast
+		column.Tenant = ormTag.Tenant
+
+		// Ciphertext outgrows the plaintext's column type
+		column.Encrypted = ormTag.Encrypted || ormTag.BlindIndex != ""
+		column.BlindIndex = ormTag.BlindIndex
+		if column.Encrypted {
+			column.Type = "TEXT"
+		}
 
 		// Set soft delete flag
 		column.SoftDelete = ormTag.SoftDelete
@@ -160,6 +191,9 @@ func (mm *Manager) extractColumn(field reflect.StructField) (*interfaces.Column,
 		}
 	}
 
+	// Parse validation rules
+	column.Validation = validation.Parse(column.Name, field.Tag.Get(TagValidate))
+
 	return column, nil
 }
 
@@ -193,11 +227,21 @@ func (mm *Manager) extractRelations(t reflect.Type, metadata *interfaces.ModelMe
 				if ormTag.ForeignKey != "" {
 					relation.ForeignKey = ormTag.ForeignKey
 				}
+				relation.Cascade = ormTag.Cascade
+
+				// Relations serialize under their lowercased field name
+				if ormTag.Hidden {
+					metadata.Hidden = append(metadata.Hidden, strings.ToLower(field.Name))
+				}
+				if ormTag.Visible {
+					metadata.Visible = append(metadata.Visible, strings.ToLower(field.Name))
+				}
 			} else {
 				// Fall back to old tag format
 				if fk := field.Tag.Get("foreign_key"); fk != "" {
 					relation.ForeignKey = fk
 				}
+				relation.Cascade = field.Tag.Get("cascade") == "true"
 			}
 
 			if rk := field.Tag.Get("referenced_key"); rk != "" {
@@ -245,6 +289,94 @@ func (mm *Manager) extractIndexes(t reflect.Type, metadata *interfaces.ModelMeta
 	}
 }
 
+// extractAppends collects the computed attributes listed in `appends` tags, such as
+// `appends:"full_name,age_group"`
+func (mm *Manager) extractAppends(t reflect.Type, metadata *interfaces.ModelMetadata) {
+	for i := 0; i < t.NumField(); i++ {
+		for _, name := range strings.Split(t.Field(i).Tag.Get(TagAppends), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				metadata.Appends = append(metadata.Appends, name)
+			}
+		}
+	}
+}
+
+// extractAuditable marks the model auditable when a field's orm tag has the auditable flag,
+// conventionally its primary key's, as in `orm:"pk,auto,auditable"`
+func (mm *Manager) extractAuditable(t reflect.Type, metadata *interfaces.ModelMetadata) {
+	for i := 0; i < t.NumField(); i++ {
+		if parseORMTag(t.Field(i).Tag.Get(TagORM)).Auditable {
+			metadata.Auditable = true
+			return
+		}
+	}
+}
+
+// HookNames lists the methods discovered as model hooks, each with the signature
+// func(ctx context.Context, orm interfaces.ORM) error
+var HookNames = []string{
+	"BeforeCreate", "AfterCreate",
+	"BeforeUpdate", "AfterUpdate",
+	"BeforeSave", "AfterSave",
+	"BeforeDelete", "AfterDelete",
+	"AfterFind",
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	ormType     = reflect.TypeOf((*interfaces.ORM)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// extractHooks registers the model's hook methods, on the value or pointer receiver. A
+// method named after a hook with another signature is an error rather than silently ignored.
+func (mm *Manager) extractHooks(t reflect.Type, metadata *interfaces.ModelMetadata) error {
+	ptrType := reflect.PointerTo(t)
+	for _, name := range HookNames {
+		method, ok := ptrType.MethodByName(name)
+		if !ok {
+			continue
+		}
+		methodType := method.Type
+		if methodType.NumIn() != 3 || methodType.In(1) != contextType || methodType.In(2) != ormType ||
+			methodType.NumOut() != 1 || methodType.Out(0) != errorType {
+			return fmt.Errorf("hook %s.%s must have the signature func(context.Context, interfaces.ORM) error", t.Name(), name)
+		}
+
+		if metadata.Hooks == nil {
+			metadata.Hooks = &interfaces.ModelHooks{}
+		}
+		if metadata.Hooks.Methods == nil {
+			metadata.Hooks.Methods = make(map[string]interfaces.HookFunc)
+		}
+		metadata.Hooks.Methods[name] = hookMethod(t, method)
+	}
+	return nil
+}
+
+// hookMethod adapts a hook method to a HookFunc. Entities passed by value are copied, so
+// changes made by the hook only reach entities passed by pointer.
+func hookMethod(t reflect.Type, method reflect.Method) interfaces.HookFunc {
+	return func(ctx context.Context, orm interfaces.ORM, entity interface{}) error {
+		receiver := reflect.ValueOf(entity)
+		if receiver.Kind() != reflect.Ptr {
+			copied := reflect.New(t)
+			copied.Elem().Set(receiver)
+			receiver = copied
+		}
+
+		ormValue := reflect.Zero(ormType)
+		if orm != nil {
+			ormValue = reflect.ValueOf(orm)
+		}
+		results := method.Func.Call([]reflect.Value{receiver, reflect.ValueOf(ctx), ormValue})
+		if err, _ := results[0].Interface().(error); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
 // parseORMTag parses ORM tags like "primary,auto" or "column:title,index"
 func parseORMTag(tag string) *ORMTag {
 	if tag == "" {
@@ -281,6 +413,10 @@ func parseORMTag(tag string) *ORMTag {
 				ormTag.Relation = value
 			case "type":
 				ormTag.RelationType = value
+			case "cast":
+				ormTag.Cast = value
+			case "blind_index":
+				ormTag.BlindIndex = value
 			}
 		} else {
 			// Handle boolean flags like "primary", "auto"
@@ -297,6 +433,26 @@ func parseORMTag(tag string) *ORMTag {
 				ormTag.Nullable = true
 			case "soft":
 				ormTag.SoftDelete = true
+			case "cascade":
+				ormTag.Cascade = true
+			case "sensitive":
+				ormTag.Sensitive = true
+			case "version":
+				ormTag.Version = true
+			case "fillable":
+				ormTag.Fillable = true
+			case "guarded":
+				ormTag.Guarded = true
+			case "hidden":
+				ormTag.Hidden = true
+			case "visible":
+				ormTag.Visible = true
+			case "encrypted":
+				ormTag.Encrypted = true
+			case "auditable":
+				ormTag.Auditable = true
+			case "tenant":
+				ormTag.Tenant = true
 			}
 		}
 	}
@@ -74,6 +74,57 @@ func (mm *Manager) ExtractMetadata(model interface{}) (*interfaces.ModelMetadata
 				metadata.SoftDeletes = true
 				metadata.DeletedAt = column.Name
 			}
+
+			// Detect tenant column
+			if column.Tenant {
+				metadata.Tenant = column.Name
+			}
+
+			// Detect optimistic locking column
+			if column.Version {
+				metadata.Version = column.Name
+			}
+
+			// Collect mass assignment rules
+			if column.Fillable {
+				metadata.Fillable = append(metadata.Fillable, column.Name)
+			}
+			if column.Guarded {
+				metadata.Guarded = append(metadata.Guarded, column.Name)
+			}
+
+			// Collect serialization rules
+			if column.Hidden {
+				metadata.Hidden = append(metadata.Hidden, column.Name)
+			}
+			if column.Visible {
+				metadata.Visible = append(metadata.Visible, column.Name)
+			}
+			if column.Cast != "" {
+				if metadata.Casts == nil {
+					metadata.Casts = make(map[string]string)
+				}
+				metadata.Casts[column.Name] = column.Cast
+			}
+
+			// Collect validation rules
+			metadata.Validation = append(metadata.Validation, column.Validation...)
+
+			// Collect encrypted columns; a blind index is stored in a column of its own
+			if column.Encrypted {
+				if metadata.Encrypted == nil {
+					metadata.Encrypted = make(map[string]string)
+				}
+				metadata.Encrypted[column.Name] = column.BlindIndex
+				if column.BlindIndex != "" {
+					metadata.Columns = append(metadata.Columns, interfaces.Column{
+						Name:     column.BlindIndex,
+						Type:     "VARCHAR(64)",
+						Index:    true,
+						Nullable: true,
+					})
+				}
+			}
 		}
 	}
 
@@ -83,6 +134,17 @@ func (mm *Manager) ExtractMetadata(model interface{}) (*interfaces.ModelMetadata
 	// Extract indexes
 	mm.extractIndexes(t, metadata)
 
+	// Extract computed attributes
+	mm.extractAppends(t, metadata)
+
+	// Detect auditing
+	mm.extractAuditable(t, metadata)
+
+	// Discover hook methods
+	if err := mm.extractHooks(t, metadata); err != nil {
+		return nil, err
+	}
+
 	// Cache the metadata
 	mm.metadata[t] = metadata
 
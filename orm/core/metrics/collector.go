@@ -0,0 +1,338 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// DefaultBuckets are the latency histogram upper bounds, in seconds
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Source provides metric snapshots to exporters
+type Source interface {
+	Snapshot() Snapshot
+}
+
+// Exporter exposes the metrics of a Source to a monitoring system
+type Exporter interface {
+	Register(source Source) error
+}
+
+// Snapshot is a point-in-time copy of the collected metrics
+type Snapshot struct {
+	Queries      []QuerySeries
+	Errors       map[string]int64
+	Transactions TransactionStats
+	Pool         *PoolStats
+}
+
+// QuerySeries is the latency histogram of one operation on one table
+type QuerySeries struct {
+	Operation string
+	Table     string
+	Count     int64
+	Sum       float64 // seconds
+	Buckets   []Bucket
+}
+
+// Bucket counts the calls that took at most UpperBound seconds
+type Bucket struct {
+	UpperBound float64
+	Count      int64
+}
+
+// TransactionStats counts transaction outcomes
+type TransactionStats struct {
+	Begun      int64
+	Committed  int64
+	RolledBack int64
+}
+
+// PoolStats reports the state of the connection pool
+type PoolStats struct {
+	MaxOpen      int
+	Open         int
+	InUse        int
+	Idle         int
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// Collector records database calls as an interceptor. It is safe for concurrent use.
+type Collector struct {
+	mu           sync.Mutex
+	buckets      []float64
+	queries      map[seriesKey]*histogram
+	errors       map[string]int64
+	transactions TransactionStats
+	pool         func() sql.DBStats
+	classify     func(error) string
+}
+
+// seriesKey identifies a latency histogram
+type seriesKey struct {
+	operation string
+	table     string
+}
+
+// histogram holds non-cumulative bucket counts
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+// NewCollector creates a collector using DefaultBuckets and ClassifyError
+func NewCollector() *Collector {
+	return &Collector{
+		buckets:  DefaultBuckets,
+		queries:  make(map[seriesKey]*histogram),
+		errors:   make(map[string]int64),
+		classify: ClassifyError,
+	}
+}
+
+// Instrument creates a collector, installs it on orm and observes its connection pool when available
+func Instrument(orm interfaces.ORM) *Collector {
+	collector := NewCollector()
+	collector.ObservePool(orm.GetDialect())
+	orm.Use(collector.Intercept)
+	return collector
+}
+
+// SetBuckets replaces the latency histogram upper bounds, in seconds
+func (c *Collector) SetBuckets(buckets []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buckets = append([]float64(nil), buckets...)
+	sort.Float64s(c.buckets)
+	c.queries = make(map[seriesKey]*histogram)
+}
+
+// SetClassifier replaces the function naming the class of an error; nil restores ClassifyError
+func (c *Collector) SetClassifier(classify func(error) string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if classify == nil {
+		classify = ClassifyError
+	}
+	c.classify = classify
+}
+
+// ObservePool reports pool statistics from dialect, looking through dialect wrappers for
+// a DB() *sql.DB accessor. Dialects without one are ignored.
+func (c *Collector) ObservePool(dialect interfaces.Dialect) {
+	for dialect != nil {
+		if source, ok := dialect.(interface{ DB() *sql.DB }); ok {
+			if db := source.DB(); db != nil {
+				c.mu.Lock()
+				c.pool = db.Stats
+				c.mu.Unlock()
+			}
+			return
+		}
+		wrapper, ok := dialect.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			return
+		}
+		dialect = wrapper.Unwrap()
+	}
+}
+
+// Intercept times every database call and records its outcome
+func (c *Collector) Intercept(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+	start := time.Now()
+	result, err := next(ctx, stmt)
+	c.observe(stmt, time.Since(start), err)
+	return result, err
+}
+
+// observe records a finished call
+func (c *Collector) observe(stmt interfaces.Statement, duration time.Duration, err error) {
+	key := seriesKey{operation: string(stmt.Kind)}
+	if stmt.SQL != "" {
+		key.operation, key.table = statementShape(stmt.SQL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.queries[key]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(c.buckets))}
+		c.queries[key] = h
+	}
+	seconds := duration.Seconds()
+	h.count++
+	h.sum += seconds
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+
+	if err != nil {
+		c.errors[c.classify(err)]++
+		return
+	}
+
+	switch stmt.Kind {
+	case interfaces.StatementBegin:
+		c.transactions.Begun++
+	case interfaces.StatementCommit:
+		c.transactions.Committed++
+	case interfaces.StatementRollback:
+		c.transactions.RolledBack++
+	}
+}
+
+// Snapshot returns a copy of the collected metrics, series sorted by operation and table
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := Snapshot{
+		Errors:       make(map[string]int64, len(c.errors)),
+		Transactions: c.transactions,
+	}
+	for class, count := range c.errors {
+		snapshot.Errors[class] = count
+	}
+
+	for key, h := range c.queries {
+		series := QuerySeries{
+			Operation: key.operation,
+			Table:     key.table,
+			Count:     h.count,
+			Sum:       h.sum,
+			Buckets:   make([]Bucket, len(c.buckets)),
+		}
+		var cumulative int64
+		for i, bound := range c.buckets {
+			cumulative += h.counts[i]
+			series.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+		}
+		snapshot.Queries = append(snapshot.Queries, series)
+	}
+	sort.Slice(snapshot.Queries, func(i, j int) bool {
+		a, b := snapshot.Queries[i], snapshot.Queries[j]
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+		return a.Table < b.Table
+	})
+
+	if c.pool != nil {
+		stats := c.pool()
+		snapshot.Pool = &PoolStats{
+			MaxOpen:      stats.MaxOpenConnections,
+			Open:         stats.OpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration,
+		}
+	}
+
+	return snapshot
+}
+
+// Export registers the collector with each exporter
+func (c *Collector) Export(exporters ...Exporter) error {
+	for _, exporter := range exporters {
+		if err := exporter.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassifyError names the class of a database error: timeout, canceled, connection,
+// deadlock, constraint, no_rows, transaction or query
+func ClassifyError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, interfaces.ErrConnection), errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return "connection"
+	case errors.Is(err, interfaces.ErrDeadlock):
+		return "deadlock"
+	case errors.Is(err, &interfaces.ErrUniqueViolation{}), errors.Is(err, &interfaces.ErrForeignKeyViolation{}),
+		errors.Is(err, &interfaces.ErrNotNullViolation{}):
+		return "constraint"
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, interfaces.ErrNotFound):
+		return "no_rows"
+	case errors.Is(err, sql.ErrTxDone):
+		return "transaction"
+	default:
+		return "query"
+	}
+}
+
+// statementShape extracts the operation and main table of a SQL statement
+func statementShape(query string) (operation, table string) {
+	words := strings.Fields(stripComments(query))
+	if len(words) == 0 {
+		return "other", ""
+	}
+
+	operation = strings.ToLower(words[0])
+	var marker string
+	switch operation {
+	case "select", "delete":
+		marker = "FROM"
+	case "insert", "replace":
+		marker = "INTO"
+	case "update":
+		if len(words) > 1 {
+			return operation, cleanTable(words[1])
+		}
+		return operation, ""
+	default:
+		return operation, ""
+	}
+
+	for i := 1; i < len(words)-1; i++ {
+		if strings.EqualFold(words[i], marker) {
+			return operation, cleanTable(words[i+1])
+		}
+	}
+	return operation, ""
+}
+
+// stripComments removes leading /* ... */ comments such as tracing annotations
+func stripComments(query string) string {
+	query = strings.TrimSpace(query)
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return ""
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+	return query
+}
+
+// cleanTable strips quoting and trailing punctuation from a table reference
+func cleanTable(word string) string {
+	if i := strings.IndexAny(word, "(,;"); i >= 0 {
+		word = word[:i]
+	}
+	return strings.Trim(word, "`\"")
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// ExpvarExporter publishes metrics as an expvar variable, served under /debug/vars
+type ExpvarExporter struct {
+	Name string
+}
+
+// NewExpvarExporter creates an exporter publishing under name
+func NewExpvarExporter(name string) *ExpvarExporter {
+	return &ExpvarExporter{Name: name}
+}
+
+// Register publishes the snapshots of source. Names can only be published once per process.
+func (e *ExpvarExporter) Register(source Source) error {
+	if expvar.Get(e.Name) != nil {
+		return fmt.Errorf("expvar %q is already published", e.Name)
+	}
+	expvar.Publish(e.Name, expvar.Func(func() interface{} {
+		return source.Snapshot()
+	}))
+	return nil
+}
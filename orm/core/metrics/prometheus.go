@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrometheusExporter serves metrics in the Prometheus text exposition format.
+// It implements http.Handler.
+type PrometheusExporter struct {
+	mu     sync.RWMutex
+	source Source
+}
+
+// NewPrometheusHandler returns an HTTP handler serving the metrics of source
+func NewPrometheusHandler(source Source) *PrometheusExporter {
+	return &PrometheusExporter{source: source}
+}
+
+// Register sets the source served by the exporter
+func (e *PrometheusExporter) Register(source Source) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.source = source
+	return nil
+}
+
+// ServeHTTP writes the current snapshot
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	source := e.source
+	e.mu.RUnlock()
+
+	if source == nil {
+		http.Error(w, "no metrics source registered", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WritePrometheus(w, source.Snapshot())
+}
+
+// WritePrometheus writes a snapshot in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, snapshot Snapshot) error {
+	out := bufio.NewWriter(w)
+
+	writeHeader(out, "orm_query_duration_seconds", "histogram", "Latency of database calls by operation and table.")
+	for _, series := range snapshot.Queries {
+		labels := fmt.Sprintf(`operation="%s",table="%s"`, escapeLabel(series.Operation), escapeLabel(series.Table))
+		for _, bucket := range series.Buckets {
+			fmt.Fprintf(out, "orm_query_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bucket.UpperBound), bucket.Count)
+		}
+		fmt.Fprintf(out, "orm_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, series.Count)
+		fmt.Fprintf(out, "orm_query_duration_seconds_sum{%s} %s\n", labels, formatFloat(series.Sum))
+		fmt.Fprintf(out, "orm_query_duration_seconds_count{%s} %d\n", labels, series.Count)
+	}
+
+	writeHeader(out, "orm_errors_total", "counter", "Database errors by class.")
+	classes := make([]string, 0, len(snapshot.Errors))
+	for class := range snapshot.Errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(out, "orm_errors_total{class=\"%s\"} %d\n", escapeLabel(class), snapshot.Errors[class])
+	}
+
+	writeHeader(out, "orm_transactions_total", "counter", "Transactions by outcome.")
+	fmt.Fprintf(out, "orm_transactions_total{outcome=\"begun\"} %d\n", snapshot.Transactions.Begun)
+	fmt.Fprintf(out, "orm_transactions_total{outcome=\"committed\"} %d\n", snapshot.Transactions.Committed)
+	fmt.Fprintf(out, "orm_transactions_total{outcome=\"rolled_back\"} %d\n", snapshot.Transactions.RolledBack)
+
+	if pool := snapshot.Pool; pool != nil {
+		writeHeader(out, "orm_pool_connections", "gauge", "Connections in the pool by state.")
+		fmt.Fprintf(out, "orm_pool_connections{state=\"open\"} %d\n", pool.Open)
+		fmt.Fprintf(out, "orm_pool_connections{state=\"in_use\"} %d\n", pool.InUse)
+		fmt.Fprintf(out, "orm_pool_connections{state=\"idle\"} %d\n", pool.Idle)
+		writeHeader(out, "orm_pool_max_open_connections", "gauge", "Maximum number of open connections.")
+		fmt.Fprintf(out, "orm_pool_max_open_connections %d\n", pool.MaxOpen)
+		writeHeader(out, "orm_pool_wait_total", "counter", "Connections waited for.")
+		fmt.Fprintf(out, "orm_pool_wait_total %d\n", pool.WaitCount)
+		writeHeader(out, "orm_pool_wait_seconds_total", "counter", "Time spent waiting for a connection.")
+		fmt.Fprintf(out, "orm_pool_wait_seconds_total %s\n", formatFloat(pool.WaitDuration.Seconds()))
+	}
+
+	return out.Flush()
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatFloat formats a sample value
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
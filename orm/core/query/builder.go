@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ESGI-M2/GO/orm/core/encryption"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tenancy"
 )
 
 // BuilderImpl implements the QueryBuilder interface
@@ -45,11 +47,21 @@ type BuilderImpl struct {
 	cursorValue   interface{}
 	page          int
 	perPage       int
+	trashed       string
+	usePrimary    bool
+	tenantScoped  bool
 }
 
+// Soft delete visibility modes
+const (
+	trashedExcluded = ""
+	trashedIncluded = "with"
+	trashedOnly     = "only"
+)
+
 // NewBuilder creates a new query builder
 func NewBuilder(orm interfaces.ORM, metadata *interfaces.ModelMetadata) *BuilderImpl {
-	return &BuilderImpl{
+	qb := &BuilderImpl{
 		Orm:           orm,
 		Metadata:      metadata,
 		table:         metadata.TableName,
@@ -66,6 +78,8 @@ func NewBuilder(orm interfaces.ORM, metadata *interfaces.ModelMetadata) *Builder
 		unions:        make([]interfaces.QueryBuilder, 0),
 		unionAlls:     make([]interfaces.QueryBuilder, 0),
 	}
+	qb.scopeTenant()
+	return qb
 }
 
 // NewRawBuilder creates a new raw SQL query builder
@@ -107,6 +121,18 @@ func (qb *BuilderImpl) Where(field, operator string, value interface{}) interfac
 		return qb
 	}
 
+	if qb.encrypted(field) {
+		switch strings.TrimSpace(operator) {
+		case "=", "!=", "<>":
+		default:
+			qb.Err = fmt.Errorf("column %s is encrypted and only supports equality lookups", field)
+			return qb
+		}
+		if field, value, qb.Err = qb.blindIndex(field, value); qb.Err != nil {
+			return qb
+		}
+	}
+
 	qb.where = append(qb.where, interfaces.WhereCondition{
 		Field:    field,
 		Operator: operator,
@@ -131,9 +157,20 @@ func (qb *BuilderImpl) WhereIn(field string, values []interface{}) interfaces.Qu
 		return qb
 	}
 
+	if qb.encrypted(field) {
+		indexed := make([]interface{}, len(values))
+		column := field
+		for i, value := range values {
+			if column, indexed[i], qb.Err = qb.blindIndex(field, value); qb.Err != nil {
+				return qb
+			}
+		}
+		field, values = column, indexed
+	}
+
 	placeholders := make([]string, len(values))
 	for i := range values {
-		placeholders[i] = qb.Orm.GetDialect().GetPlaceholder(i)
+		placeholders[i] = qb.Orm.GetDialect().GetPlaceholder(len(qb.args))
 		qb.args = append(qb.args, values[i])
 	}
 
@@ -160,7 +197,7 @@ func (qb *BuilderImpl) WhereNotIn(field string, values []interface{}) interfaces
 
 	placeholders := make([]string, len(values))
 	for i := range values {
-		placeholders[i] = qb.Orm.GetDialect().GetPlaceholder(i)
+		placeholders[i] = qb.Orm.GetDialect().GetPlaceholder(len(qb.args))
 		qb.args = append(qb.args, values[i])
 	}
 
@@ -404,7 +441,8 @@ func (qb *BuilderImpl) SubQuery(alias string, fn func(interfaces.QueryBuilder) i
 	return qb
 }
 
-// With adds eager loading for relations
+// With adds eager loading for relations; fn constrains the relation's query and may be nil.
+// Queries with constrained relations are not cached.
 func (qb *BuilderImpl) With(relation string, fn func(interfaces.QueryBuilder) interfaces.QueryBuilder) interfaces.QueryBuilder {
 	if qb.Err != nil {
 		return qb
@@ -546,6 +584,108 @@ func (qb *BuilderImpl) WithoutCache() interfaces.QueryBuilder {
 	return qb
 }
 
+// WithTrashed includes soft-deleted rows in the results
+func (qb *BuilderImpl) WithTrashed() interfaces.QueryBuilder {
+	if qb.Err != nil {
+		return qb
+	}
+
+	qb.trashed = trashedIncluded
+	return qb
+}
+
+// OnlyTrashed restricts the results to soft-deleted rows
+func (qb *BuilderImpl) OnlyTrashed() interfaces.QueryBuilder {
+	if qb.Err != nil {
+		return qb
+	}
+
+	qb.trashed = trashedOnly
+	return qb
+}
+
+// UsePrimary sends the query to the primary database even when read replicas are
+// configured, so that it sees the caller's latest writes
+func (qb *BuilderImpl) UsePrimary() interfaces.QueryBuilder {
+	if qb.Err != nil {
+		return qb
+	}
+
+	qb.usePrimary = true
+	return qb
+}
+
+// encrypted reports whether field, optionally qualified by the table, is an encrypted column
+func (qb *BuilderImpl) encrypted(field string) bool {
+	if qb.Metadata == nil {
+		return false
+	}
+	_, ok := qb.Metadata.Encrypted[strings.TrimPrefix(field, qb.Metadata.TableName+".")]
+	return ok
+}
+
+// blindIndex rewrites a lookup on an encrypted column to its blind index, since the
+// ciphertext differs on every write
+func (qb *BuilderImpl) blindIndex(field string, value interface{}) (string, interface{}, error) {
+	column := strings.TrimPrefix(field, qb.Metadata.TableName+".")
+	index := qb.Metadata.Encrypted[column]
+	if index == "" {
+		return "", nil, fmt.Errorf("column %s is encrypted and has no blind index to look it up by", column)
+	}
+
+	hash, err := encryption.IndexValue(value, encryption.AAD(qb.Metadata.TableName, column))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to index %s: %w", column, err)
+	}
+	if column != field {
+		index = qb.Metadata.TableName + "." + index
+	}
+	return index, hash, nil
+}
+
+// scopeTenant restricts the query to the tenant of the ORM's context, or fails it when the
+// context has no tenant and does not bypass tenant scoping
+func (qb *BuilderImpl) scopeTenant() {
+	if qb.Orm == nil || qb.Metadata.Tenant == "" {
+		return
+	}
+	tenant, scoped, err := tenancy.Scope(qb.Orm.Context(), qb.Metadata.TableName)
+	if err != nil {
+		qb.Err = err
+		return
+	}
+	if scoped {
+		qb.Where(fmt.Sprintf("%s.%s", qb.table, qb.Metadata.Tenant), "=", tenant)
+		qb.tenantScoped = true
+	}
+}
+
+// whereClause joins the WHERE conditions. The tenant condition, always first, is kept
+// apart from the others so a raw condition such as "a OR b" cannot widen it.
+func (qb *BuilderImpl) whereClause(conditions []string) string {
+	if qb.tenantScoped && len(conditions) > 1 {
+		return fmt.Sprintf("%s AND (%s)", conditions[0], strings.Join(conditions[1:], " AND "))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// softDeleteCondition returns the condition hiding or selecting soft-deleted rows
+func (qb *BuilderImpl) softDeleteCondition() string {
+	if qb.Metadata == nil || !qb.Metadata.SoftDeletes || qb.Metadata.DeletedAt == "" {
+		return ""
+	}
+
+	column := fmt.Sprintf("%s.%s", qb.table, qb.Metadata.DeletedAt)
+	switch qb.trashed {
+	case trashedIncluded:
+		return ""
+	case trashedOnly:
+		return fmt.Sprintf("%s IS NOT NULL", column)
+	default:
+		return fmt.Sprintf("%s IS NULL", column)
+	}
+}
+
 // OrderBy adds an ORDER BY clause
 func (qb *BuilderImpl) OrderBy(field, direction string) interfaces.QueryBuilder {
 	if qb.Err != nil {
@@ -665,20 +805,21 @@ func (qb *BuilderImpl) GetSQL() string {
 	}
 
 	// WHERE clause
-	if len(qb.where) > 0 {
-		var conditions []string
-		for _, condition := range qb.where {
-			if condition.Field != "" {
-				if condition.Operator != "" && condition.Value != nil {
-					conditions = append(conditions, fmt.Sprintf("%s %s ?", condition.Field, condition.Operator))
-				} else if condition.Field != "" {
-					conditions = append(conditions, condition.Field)
-				}
+	var conditions []string
+	for _, condition := range qb.where {
+		if condition.Field != "" {
+			if condition.Operator != "" && condition.Value != nil {
+				conditions = append(conditions, fmt.Sprintf("%s %s ?", condition.Field, condition.Operator))
+			} else if condition.Field != "" {
+				conditions = append(conditions, condition.Field)
 			}
 		}
-		if len(conditions) > 0 {
-			parts = append(parts, "WHERE", strings.Join(conditions, " AND "))
-		}
+	}
+	if softDelete := qb.softDeleteCondition(); softDelete != "" {
+		conditions = append(conditions, softDelete)
+	}
+	if len(conditions) > 0 {
+		parts = append(parts, "WHERE", qb.whereClause(conditions))
 	}
 
 	// GROUP BY clause
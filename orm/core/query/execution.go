@@ -7,9 +7,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/ESGI-M2/GO/orm/core/casts"
+	"github.com/ESGI-M2/GO/orm/core/encryption"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/sharding"
+	"github.com/ESGI-M2/GO/orm/core/singleflight"
 )
 
 // Find executes the query and returns all results
@@ -29,26 +35,25 @@ func (qb *BuilderImpl) Find() ([]map[string]interface{}, error) {
 		return qb.executeRaw()
 	}
 
-	sql := qb.buildQuery()
-	rows, err := qb.Orm.GetDialect().Query(sql, qb.args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
-	}
-	if rows != nil {
-		defer rows.Close()
-	}
+	value, coalesced, err := qb.coalesce("find", func() (interface{}, error) {
+		results, err := qb.fetch()
+		if err != nil {
+			return nil, err
+		}
 
-	results, err := qb.scanRows(rows)
+		// Load relations if specified
+		if len(qb.withRelations) > 0 {
+			return qb.loadRelations(results)
+		}
+		return results, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Load relations if specified
-	if len(qb.withRelations) > 0 {
-		results, err = qb.loadRelations(results)
-		if err != nil {
-			return nil, err
-		}
+	results := value.([]map[string]interface{})
+	if coalesced {
+		results = copyResults(results)
 	}
 
 	// Cache results if enabled
@@ -59,7 +64,7 @@ func (qb *BuilderImpl) Find() ([]map[string]interface{}, error) {
 	return results, nil
 }
 
-// FindOne executes the query and returns one result
+// FindOne executes the query and returns one result, or ErrNotFound when nothing matches
 func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 	if qb.Err != nil {
 		return nil, qb.Err
@@ -67,11 +72,8 @@ func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 
 	// Check cache first
 	if qb.useCache {
-		if cached, found := qb.getFromCache(); found {
-			if len(cached) > 0 {
-				return cached[0], nil
-			}
-			return nil, nil
+		if cached, found := qb.getOneFromCache(); found {
+			return cached, nil
 		}
 	}
 
@@ -81,7 +83,7 @@ func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 			return nil, err
 		}
 		if len(results) == 0 {
-			return nil, nil
+			return nil, interfaces.ErrNotFound
 		}
 		return results[0], nil
 	}
@@ -90,16 +92,9 @@ func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 	originalLimit := qb.limit
 	qb.limit = 1
 
-	sql := qb.buildQuery()
-	rows, err := qb.Orm.GetDialect().Query(sql, qb.args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
-	}
-	if rows != nil {
-		defer rows.Close()
-	}
-
-	results, err := qb.scanRows(rows)
+	value, coalesced, err := qb.coalesce("one", func() (interface{}, error) {
+		return qb.fetch()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -107,11 +102,15 @@ func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 	// Restore original limit
 	qb.limit = originalLimit
 
+	results := value.([]map[string]interface{})
 	if len(results) == 0 {
-		return nil, nil
+		return nil, interfaces.ErrNotFound
 	}
 
 	result := results[0]
+	if coalesced {
+		result = copyRow(result)
+	}
 
 	// Load relations if specified
 	if len(qb.withRelations) > 0 {
@@ -126,7 +125,7 @@ func (qb *BuilderImpl) FindOne() (map[string]interface{}, error) {
 
 	// Cache result if enabled
 	if qb.useCache {
-		qb.setCache([]map[string]interface{}{result})
+		qb.setOneCache(result)
 	}
 
 	return result, nil
@@ -153,18 +152,35 @@ func (qb *BuilderImpl) Count() (int64, error) {
 	originalFields := qb.fields
 	qb.fields = []string{"COUNT(*)"}
 
-	sql := qb.buildQuery()
-	row := qb.Orm.GetDialect().QueryRow(sql, qb.args...)
-
-	var count int64
-	if row != nil {
-		err := row.Scan(&count)
+	value, _, err := qb.coalesce("count", func() (interface{}, error) {
+		shards, err := qb.shardDialects()
 		if err != nil {
-			return 0, fmt.Errorf("failed to scan count: %w", err)
+			return nil, err
 		}
-	} else {
-		count = 0
+		if len(shards) == 0 {
+			shards = []interfaces.Dialect{qb.readDialect()}
+		}
+
+		// Sharded tables hold disjoint rows, so per-shard counts add up
+		query := qb.buildQuery()
+		var total int64
+		for _, shard := range shards {
+			row := shard.QueryRow(query, qb.args...)
+
+			var count int64
+			if row != nil {
+				if err := row.Scan(&count); err != nil {
+					return nil, fmt.Errorf("failed to scan count: %w", translateError(shard, err))
+				}
+			}
+			total += count
+		}
+		return total, nil
+	})
+	if err != nil {
+		return 0, err
 	}
+	count := value.(int64)
 
 	// Restore original fields
 	qb.fields = originalFields
@@ -206,19 +222,28 @@ func (qb *BuilderImpl) Exists() (bool, error) {
 	originalLimit := qb.limit
 	qb.limit = 1
 
-	sql := qb.buildQuery()
-	rows, err := qb.Orm.GetDialect().Query(sql, qb.args...)
-	if err != nil {
-		return false, fmt.Errorf("failed to execute exists query: %w", err)
-	}
+	value, _, err := qb.coalesce("exists", func() (interface{}, error) {
+		shards, err := qb.shardDialects()
+		if err != nil {
+			return nil, err
+		}
+		if len(shards) == 0 {
+			shards = []interfaces.Dialect{qb.readDialect()}
+		}
 
-	var exists bool
-	if rows != nil {
-		defer rows.Close()
-		exists = rows.Next()
-	} else {
-		exists = false
+		query := qb.buildQuery()
+		for _, shard := range shards {
+			found, err := existsOn(shard, query, qb.args)
+			if err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, err
 	}
+	exists := value.(bool)
 
 	// Restore original fields and limit
 	qb.fields = originalFields
@@ -232,6 +257,36 @@ func (qb *BuilderImpl) Exists() (bool, error) {
 	return exists, nil
 }
 
+// translateError maps a driver error to the ORM's typed errors when the dialect, or a
+// dialect it wraps, knows how to. Errors surfacing from a *sql.Row scan need this since
+// the dialect never sees them.
+func translateError(dialect interfaces.Dialect, err error) error {
+	for dialect != nil {
+		if translator, ok := dialect.(interface{ TranslateError(err error) error }); ok {
+			return translator.TranslateError(err)
+		}
+		wrapper, ok := dialect.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			break
+		}
+		dialect = wrapper.Unwrap()
+	}
+	return err
+}
+
+// existsOn reports whether query returns a row on dialect
+func existsOn(dialect interfaces.Dialect, query string, args []interface{}) (bool, error) {
+	rows, err := dialect.Query(query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute exists query: %w", err)
+	}
+	if rows == nil {
+		return false, nil
+	}
+	defer rows.Close()
+	return rows.Next(), nil
+}
+
 // Paginate executes the query with pagination
 func (qb *BuilderImpl) Paginate(page, perPage int) (*interfaces.PaginationResult, error) {
 	if qb.Err != nil {
@@ -291,25 +346,26 @@ func (qb *BuilderImpl) buildQuery() string {
 	}
 
 	// WHERE clause
-	if len(qb.where) > 0 {
-		var conditions []string
-		argIndex := 0
-		for _, condition := range qb.where {
-			if condition.Field != "" {
-				if condition.Raw {
-					// Raw WHERE conditions are used as-is
-					conditions = append(conditions, condition.Field)
-				} else if condition.Operator != "" && condition.Value != nil {
-					conditions = append(conditions, fmt.Sprintf("%s %s %s", condition.Field, condition.Operator, qb.Orm.GetDialect().GetPlaceholder(argIndex)))
-					argIndex++
-				} else if condition.Field != "" {
-					conditions = append(conditions, condition.Field)
-				}
+	var conditions []string
+	argIndex := 0
+	for _, condition := range qb.where {
+		if condition.Field != "" {
+			if condition.Raw {
+				// Raw WHERE conditions are used as-is
+				conditions = append(conditions, condition.Field)
+			} else if condition.Operator != "" && condition.Value != nil {
+				conditions = append(conditions, fmt.Sprintf("%s %s %s", condition.Field, condition.Operator, qb.Orm.GetDialect().GetPlaceholder(argIndex)))
+				argIndex++
+			} else if condition.Field != "" {
+				conditions = append(conditions, condition.Field)
 			}
 		}
-		if len(conditions) > 0 {
-			parts = append(parts, "WHERE", strings.Join(conditions, " AND "))
-		}
+	}
+	if softDelete := qb.softDeleteCondition(); softDelete != "" {
+		conditions = append(conditions, softDelete)
+	}
+	if len(conditions) > 0 {
+		parts = append(parts, "WHERE", qb.whereClause(conditions))
 	}
 
 	// GROUP BY clause
@@ -349,9 +405,121 @@ func (qb *BuilderImpl) buildQuery() string {
 	return strings.Join(parts, " ")
 }
 
+// fetch runs the built SELECT and scans every row, gathering them from each shard
+// when the table is sharded
+func (qb *BuilderImpl) fetch() ([]map[string]interface{}, error) {
+	shards, err := qb.shardDialects()
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) > 1 {
+		return qb.scatter(shards)
+	}
+
+	dialect := qb.readDialect()
+	if len(shards) == 1 {
+		dialect = shards[0]
+	}
+	return qb.fetchFrom(dialect, qb.buildQuery())
+}
+
+// fetchFrom runs query on dialect and scans every row
+func (qb *BuilderImpl) fetchFrom(dialect interfaces.Dialect, query string) ([]map[string]interface{}, error) {
+	rows, err := dialect.Query(query, qb.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	if rows != nil {
+		defer rows.Close()
+	}
+
+	return qb.scanRows(rows)
+}
+
+// scatter runs the query on every shard concurrently and merges the rows. Each shard
+// returns its first offset+limit rows so ordering, offset and limit apply to the merged set.
+func (qb *BuilderImpl) scatter(shards []interfaces.Dialect) ([]map[string]interface{}, error) {
+	originalLimit, originalOffset := qb.limit, qb.offset
+	if qb.limit > 0 {
+		qb.limit += qb.offset
+	}
+	qb.offset = 0
+	query := qb.buildQuery()
+	qb.limit, qb.offset = originalLimit, originalOffset
+
+	results := make([][]map[string]interface{}, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard interfaces.Dialect) {
+			defer wg.Done()
+			results[i], errs[i] = qb.fetchFrom(shard, query)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return sharding.Merge(results, qb.orderBy, qb.offset, qb.limit), nil
+}
+
+// shardDialects returns the shards the query must visit, or nil when the table is not sharded
+func (qb *BuilderImpl) shardDialects() ([]interfaces.Dialect, error) {
+	if qb.Orm == nil || qb.Metadata == nil || qb.rawSQL != "" {
+		return nil, nil
+	}
+	if router, ok := qb.Orm.(interface {
+		ShardDialects(table string, where []interfaces.WhereCondition) ([]interfaces.Dialect, error)
+	}); ok {
+		return router.ShardDialects(qb.table, qb.where)
+	}
+	return nil, nil
+}
+
+// readDialect returns the dialect executing the builder's reads
+func (qb *BuilderImpl) readDialect() interfaces.Dialect {
+	if qb.usePrimary {
+		if source, ok := qb.Orm.(interface{ PrimaryDialect() interfaces.Dialect }); ok {
+			return source.PrimaryDialect()
+		}
+	}
+	return qb.Orm.GetDialect()
+}
+
+// coalesce runs fn, sharing its result with identical reads already in flight when
+// request coalescing is enabled. coalesced reports whether the result may be shared,
+// in which case callers must copy it before handing it out.
+func (qb *BuilderImpl) coalesce(kind string, fn func() (interface{}, error)) (value interface{}, coalesced bool, err error) {
+	group := qb.coalescer()
+	if group == nil {
+		value, err = fn()
+		return value, false, err
+	}
+
+	value, err, _ = group.Do(kind+":"+qb.getCacheKey(), fn)
+	return value, true, err
+}
+
+// coalescer returns the ORM's singleflight group, or nil when reads must not be shared
+func (qb *BuilderImpl) coalescer() *singleflight.Group {
+	// Reads pinned to the primary must not share a result fetched from a replica, and
+	// relation constraint callbacks are not part of the key
+	if qb.Orm == nil || qb.rawSQL != "" || qb.usePrimary || qb.constrainedRelations() {
+		return nil
+	}
+	if source, ok := qb.Orm.(interface{ Coalescer() *singleflight.Group }); ok {
+		return source.Coalescer()
+	}
+	return nil
+}
+
 // executeRaw executes a raw SQL query
 func (qb *BuilderImpl) executeRaw() ([]map[string]interface{}, error) {
-	rows, err := qb.Orm.GetDialect().Query(qb.rawSQL, qb.rawArgs...)
+	rows, err := qb.readDialect().Query(qb.rawSQL, qb.rawArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute raw query: %w", err)
 	}
@@ -389,9 +557,22 @@ func (qb *BuilderImpl) scanRows(rows *sql.Rows) ([]map[string]interface{}, error
 		row := make(map[string]interface{})
 		for i, column := range columns {
 			val := values[i]
-			if val != nil {
-				row[column] = val
+			if val == nil {
+				continue
+			}
+			if qb.Metadata != nil {
+				if _, ok := qb.Metadata.Encrypted[column]; ok {
+					if val, err = encryption.DecryptValue(val, encryption.AAD(qb.Metadata.TableName, column)); err != nil {
+						return nil, fmt.Errorf("failed to decrypt column %s: %w", column, err)
+					}
+				}
+				if cast, ok := qb.Metadata.Casts[column]; ok {
+					if val, err = casts.Get(cast, val, nil); err != nil {
+						return nil, fmt.Errorf("failed to cast column %s: %w", column, err)
+					}
+				}
 			}
+			row[column] = val
 		}
 		results = append(results, row)
 	}
@@ -433,7 +614,12 @@ func (qb *BuilderImpl) loadRelations(results []map[string]interface{}) ([]map[st
 		relModelPtr := reflect.New(modelType).Interface()
 
 		relationQuery := qb.Orm.Query(relModelPtr)
-		relationQuery = relationFn(relationQuery).(interfaces.QueryBuilder)
+		if qb.usePrimary {
+			relationQuery.UsePrimary()
+		}
+		if relationFn != nil {
+			relationQuery = relationFn(relationQuery)
+		}
 
 		// Use the foreign key defined in relation metadata
 		fkField := relInfo.ForeignKey
@@ -469,11 +655,19 @@ func (qb *BuilderImpl) loadRelations(results []map[string]interface{}) ([]map[st
 	return results, nil
 }
 
-// getCacheKey generates a cache key for the current query
+// getCacheKey generates a cache key for the current query. Eager-loaded relations run as
+// queries of their own, so their names are part of the key.
 func (qb *BuilderImpl) getCacheKey() string {
+	relations := make([]string, 0, len(qb.withRelations))
+	for relation := range qb.withRelations {
+		relations = append(relations, relation)
+	}
+	sort.Strings(relations)
+
 	data := map[string]interface{}{
-		"sql":  qb.GetSQL(),
-		"args": qb.GetArgs(),
+		"sql":       qb.GetSQL(),
+		"args":      qb.GetArgs(),
+		"relations": relations,
 	}
 
 	jsonData, _ := json.Marshal(data)
@@ -481,37 +675,194 @@ func (qb *BuilderImpl) getCacheKey() string {
 	return hex.EncodeToString(hash[:])
 }
 
+// cacheStore returns the cache backing this builder, or nil when results must not be cached.
+// Raw queries have no known tables to invalidate and transactions may see uncommitted rows.
+// Relations loaded with a constraint callback cannot be told apart by key either.
+func (qb *BuilderImpl) cacheStore() interfaces.Cache {
+	if qb.Orm == nil || qb.rawSQL != "" || qb.Metadata == nil || qb.constrainedRelations() {
+		return nil
+	}
+	if scoped, ok := qb.Orm.(interface{ InTransaction() bool }); ok && scoped.InTransaction() {
+		return nil
+	}
+	return qb.Orm.GetCache()
+}
+
+// constrainedRelations reports whether a relation is eager loaded with a constraint callback
+func (qb *BuilderImpl) constrainedRelations() bool {
+	for _, fn := range qb.withRelations {
+		if fn != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheTags returns the tables a cached result depends on
+func (qb *BuilderImpl) cacheTags() []string {
+	tags := []string{qb.table}
+
+	for _, join := range qb.joins {
+		if fields := strings.Fields(join.Table); len(fields) > 0 {
+			tags = append(tags, fields[0])
+		}
+	}
+
+	for relationName := range qb.withRelations {
+		relInfo, ok := qb.Metadata.Relations[relationName]
+		if !ok {
+			continue
+		}
+		modelType := relInfo.TargetModel
+		for modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		if relMetadata, err := qb.Orm.GetMetadata(reflect.New(modelType).Interface()); err == nil {
+			tags = append(tags, relMetadata.TableName)
+		}
+	}
+
+	return tags
+}
+
+// cacheGet looks up a cached value for the given kind of read
+func (qb *BuilderImpl) cacheGet(kind string) (interface{}, bool) {
+	// Cached rows may come from a lagging replica; primary reads refresh them instead
+	store := qb.cacheStore()
+	if store == nil || qb.usePrimary {
+		return nil, false
+	}
+	return store.Get(kind + ":" + qb.getCacheKey())
+}
+
+// cacheSet stores a value for the given kind of read, tagged with its tables
+func (qb *BuilderImpl) cacheSet(kind string, value interface{}) {
+	store := qb.cacheStore()
+	if store == nil {
+		return
+	}
+
+	key := kind + ":" + qb.getCacheKey()
+	if tagged, ok := store.(interfaces.TaggedCache); ok {
+		tagged.SetWithTags(key, value, qb.cacheTTL, qb.cacheTags())
+		return
+	}
+	store.Set(key, value, qb.cacheTTL)
+}
+
 // getFromCache retrieves results from cache
 func (qb *BuilderImpl) getFromCache() ([]map[string]interface{}, bool) {
-	// This is a simplified cache implementation
-	// In a real implementation, you would use a proper cache like Redis or in-memory cache
-	return nil, false
+	cached, found := qb.cacheGet("find")
+	if !found {
+		return nil, false
+	}
+	results, ok := cached.([]map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return copyResults(results), true
 }
 
 // setCache stores results in cache
 func (qb *BuilderImpl) setCache(results []map[string]interface{}) {
-	// This is a simplified cache implementation
-	// In a real implementation, you would use a proper cache like Redis or in-memory cache
+	qb.cacheSet("find", copyResults(results))
+}
+
+// getOneFromCache retrieves a single result from cache
+func (qb *BuilderImpl) getOneFromCache() (map[string]interface{}, bool) {
+	cached, found := qb.cacheGet("one")
+	if !found {
+		return nil, false
+	}
+	result, ok := cached.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return copyRow(result), true
+}
+
+// setOneCache stores a single result in cache
+func (qb *BuilderImpl) setOneCache(result map[string]interface{}) {
+	qb.cacheSet("one", copyRow(result))
 }
 
 // getCountFromCache retrieves count from cache
 func (qb *BuilderImpl) getCountFromCache() (int64, bool) {
-	return 0, false
+	cached, found := qb.cacheGet("count")
+	if !found {
+		return 0, false
+	}
+	count, ok := cached.(int64)
+	return count, ok
 }
 
 // setCountCache stores count in cache
 func (qb *BuilderImpl) setCountCache(count int64) {
-	// Simplified cache implementation
+	qb.cacheSet("count", count)
 }
 
 // getExistsFromCache retrieves exists result from cache
 func (qb *BuilderImpl) getExistsFromCache() (bool, bool) {
-	return false, false
+	cached, found := qb.cacheGet("exists")
+	if !found {
+		return false, false
+	}
+	exists, ok := cached.(bool)
+	return exists, ok
 }
 
 // setExistsCache stores exists result in cache
 func (qb *BuilderImpl) setExistsCache(exists bool) {
-	// Simplified cache implementation
+	qb.cacheSet("exists", exists)
+}
+
+// copyResults copies result rows so callers cannot mutate cached or shared data
+func copyResults(results []map[string]interface{}) []map[string]interface{} {
+	if results == nil {
+		return nil
+	}
+	copied := make([]map[string]interface{}, len(results))
+	for i, row := range results {
+		copied[i] = copyRow(row)
+	}
+	return copied
+}
+
+// copyRow copies a single result row, along with its loaded relations and decoded JSON
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	if row == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(row))
+	for key, value := range row {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+// copyValue deep-copies the maps and slices a result value may hold
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyRow(v)
+	case []map[string]interface{}:
+		return copyResults(v)
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	case []byte:
+		if v == nil {
+			return v
+		}
+		return append([]byte(nil), v...)
+	}
+	return value
 }
 
 // convertToInterface converts []map[string]interface{} to []interface{}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ESGI-M2/GO/orm/core/audit"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tracking"
+)
+
+// History returns the audit entries of the record with the given ID, oldest first. The
+// history of a tenant-scoped record is only returned to its tenant.
+func (r *RepositoryImpl) History(id interface{}) ([]interfaces.AuditEntry, error) {
+	if r.metadata == nil {
+		return nil, fmt.Errorf("metadata not available")
+	}
+	_, scoped, err := r.tenantScope()
+	if err != nil {
+		return nil, err
+	}
+	if scoped {
+		if err := r.checkVisible(id); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := audit.History(r.orm.GetDialect(), r.metadata.TableName, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+	return entries, nil
+}
+
+// auditedChange reports whether a write by primary key must be audited: the model is
+// auditable and the statement matched its record
+func (r *RepositoryImpl) auditedChange(result sql.Result) (bool, error) {
+	if !r.metadata.Auditable {
+		return false, nil
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check the audited change: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// refuseUnaudited fails bulk writes of auditable models, which change records without
+// reading them and so cannot record what they change
+func (r *RepositoryImpl) refuseUnaudited(operation string) error {
+	if r.metadata.Auditable {
+		return fmt.Errorf("%s cannot be audited, change %s records one by one", operation, r.metadata.TableName)
+	}
+	return nil
+}
+
+// in returns the repository bound to another ORM, such as a transaction-scoped one
+func (r *RepositoryImpl) in(orm interfaces.ORM) *RepositoryImpl {
+	return &RepositoryImpl{orm: orm, metadata: r.metadata, model: r.model}
+}
+
+// auditOriginal returns the column values an audited change starts from: the snapshot of
+// an entity tracked since it was loaded, or else the record as stored. It returns nil for
+// models without auditing and for records that are not stored.
+func (r *RepositoryImpl) auditOriginal(entity interface{}, entityValue reflect.Value) (map[string]interface{}, error) {
+	if !r.metadata.Auditable {
+		return nil, nil
+	}
+	if original, ok := tracking.Original(entity); ok {
+		return original, nil
+	}
+
+	id := r.findFieldByColumnName(entityValue, r.metadata.PrimaryKey)
+	if !id.IsValid() {
+		return nil, fmt.Errorf("primary key field %s not found", r.metadata.PrimaryKey)
+	}
+	row, err := r.orm.Query(r.model).WithoutCache().WithTrashed().
+		Where(r.metadata.PrimaryKey, "=", id.Interface()).FindOne()
+	if errors.Is(err, interfaces.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audited record: %w", err)
+	}
+	stored, err := r.mapToStruct(row)
+	if err != nil {
+		return nil, err
+	}
+	return r.columnValues(reflect.ValueOf(stored).Elem()), nil
+}
+
+// audit records a change through dialect, the one that made it. Creates record the new
+// values, deletes the old ones and updates the columns they changed.
+func (r *RepositoryImpl) audit(dialect interfaces.Dialect, action string, entityValue reflect.Value, original, current map[string]interface{}) error {
+	if !r.metadata.Auditable {
+		return nil
+	}
+
+	if original != nil && current != nil {
+		changes := tracking.Diff(original, current)
+		original = make(map[string]interface{}, len(changes))
+		current = make(map[string]interface{}, len(changes))
+		for column, change := range changes {
+			original[column] = change.Old
+			current[column] = change.New
+		}
+	}
+
+	var id interface{}
+	if field := r.findFieldByColumnName(entityValue, r.metadata.PrimaryKey); field.IsValid() {
+		id = field.Interface()
+	}
+	err := audit.Record(dialect, interfaces.AuditEntry{
+		Table:     r.metadata.TableName,
+		RecordID:  fmt.Sprint(id),
+		Action:    action,
+		OldValues: r.redact(original),
+		NewValues: r.redact(current),
+		Actor:     audit.Actor(r.orm.Context()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to audit %s: %w", action, err)
+	}
+	return nil
+}
+
+// redact returns a copy of values hiding sensitive and encrypted columns, which the audit
+// log must not leak
+func (r *RepositoryImpl) redact(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(values))
+	for column, value := range values {
+		redacted[column] = value
+	}
+	for _, column := range r.metadata.Columns {
+		if _, ok := redacted[column.Name]; ok && (column.Sensitive || column.Encrypted) {
+			redacted[column.Name] = audit.Redacted
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Fill sets the entity's fields from data, keyed by column name, converting values to the
+// field types. Keys naming protected or unknown columns are rejected with an
+// ErrMassAssignment listing them, in which case no field is set. The primary key and the
+// version column are always protected; once a column is fillable, all others are too.
+func (r *RepositoryImpl) Fill(entity interface{}, data map[string]interface{}) error {
+	if r.metadata == nil {
+		return fmt.Errorf("metadata not available")
+	}
+
+	entityValue := reflect.ValueOf(entity)
+	if entityValue.Kind() != reflect.Ptr || entityValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("fill requires a pointer to a struct, got %T", entity)
+	}
+	entityValue = entityValue.Elem()
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Convert every value before setting any, so a bad payload leaves the entity untouched
+	fields := make([]reflect.Value, 0, len(keys))
+	values := make([]reflect.Value, 0, len(keys))
+	var rejected []string
+	for _, key := range keys {
+		column := r.fillableColumn(key)
+		if column == nil {
+			rejected = append(rejected, key)
+			continue
+		}
+		field := r.findFieldByColumnName(entityValue, column.Name)
+		if !field.IsValid() || !field.CanSet() {
+			rejected = append(rejected, key)
+			continue
+		}
+
+		value := reflect.New(field.Type()).Elem()
+		if err := assignValue(value, data[key]); err != nil {
+			return fmt.Errorf("failed to fill %s: %w", column.Name, err)
+		}
+		fields = append(fields, field)
+		values = append(values, value)
+	}
+	if len(rejected) > 0 {
+		return &interfaces.ErrMassAssignment{Table: r.metadata.TableName, Keys: rejected}
+	}
+
+	for i, field := range fields {
+		field.Set(values[i])
+	}
+	return nil
+}
+
+// CreateFrom creates a record from data with the same rules as Fill and returns it as a
+// pointer to the model struct
+func (r *RepositoryImpl) CreateFrom(data map[string]interface{}) (interface{}, error) {
+	if r.metadata == nil {
+		return nil, fmt.Errorf("metadata not available")
+	}
+
+	entity := reflect.New(r.metadata.Type).Interface()
+	if err := r.Fill(entity, data); err != nil {
+		return nil, err
+	}
+	if err := r.Create(entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// fillableColumn returns the column named by key if mass assignment may set it
+func (r *RepositoryImpl) fillableColumn(key string) *interfaces.Column {
+	for i := range r.metadata.Columns {
+		column := &r.metadata.Columns[i]
+		if !strings.EqualFold(column.Name, key) {
+			continue
+		}
+
+		switch {
+		case column.PrimaryKey, column.AutoIncrement, column.Name == r.metadata.Version:
+			return nil
+		case slices.Contains(r.metadata.Guarded, column.Name):
+			return nil
+		case len(r.metadata.Fillable) > 0 && !slices.Contains(r.metadata.Fillable, column.Name):
+			return nil
+		}
+		return column
+	}
+	return nil
+}
+
+// assignValue sets field from a loosely typed value such as a decoded JSON or form value
+func assignValue(field reflect.Value, value interface{}) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(v)
+		if err != nil || field.OverflowInt(n) {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(v)
+		if err != nil || n < 0 || field.OverflowUint(uint64(n)) {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		field.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v)
+		if err != nil || field.OverflowFloat(f) {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		field.SetFloat(f)
+	case reflect.String:
+		switch {
+		case v.Kind() == reflect.String:
+			field.SetString(v.String())
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			field.SetString(string(v.Bytes()))
+		default:
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+	case reflect.Bool:
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(v.String()))
+		if err != nil {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 || v.Kind() != reflect.String {
+			return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+		}
+		field.SetBytes([]byte(v.String()))
+	default:
+		if field.Type() == reflect.TypeOf(time.Time{}) && v.Kind() == reflect.String {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(v.String()))
+			if err != nil {
+				return fmt.Errorf("cannot convert %v to %s: %w", value, field.Type(), err)
+			}
+			field.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if v.Type().ConvertibleTo(field.Type()) && v.Kind() == field.Kind() {
+			field.Set(v.Convert(field.Type()))
+			return nil
+		}
+		return fmt.Errorf("cannot convert %v to %s", value, field.Type())
+	}
+	return nil
+}
+
+// toInt64 converts integers, integral floats and numeric strings
+func toInt64(v reflect.Value) (int64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", f)
+		}
+		return int64(f), nil
+	case reflect.String:
+		return strconv.ParseInt(strings.TrimSpace(v.String()), 10, 64)
+	}
+	return 0, fmt.Errorf("%v is not a number", v.Interface())
+}
+
+// toFloat64 converts numbers and numeric strings
+func toFloat64(v reflect.Value) (float64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+	}
+	return 0, fmt.Errorf("%v is not a number", v.Interface())
+}
@@ -1,9 +1,16 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
+
+	"github.com/ESGI-M2/GO/orm/core/casts"
+	"github.com/ESGI-M2/GO/orm/core/encryption"
+	"github.com/ESGI-M2/GO/orm/core/events"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tracking"
 )
 
 // Save saves an entity (insert or update)
@@ -12,6 +19,10 @@ func (r *RepositoryImpl) Save(entity interface{}) error {
 		return fmt.Errorf("metadata not available")
 	}
 
+	if err := r.Validate(entity); err != nil {
+		return err
+	}
+
 	// Check if entity has an ID to determine if it's an insert or update
 	entityValue := reflect.ValueOf(entity)
 	if entityValue.Kind() == reflect.Ptr {
@@ -69,15 +80,73 @@ func (r *RepositoryImpl) Delete(entity interface{}) error {
 		return fmt.Errorf("primary key field %s not found", r.metadata.PrimaryKey)
 	}
 
+	if r.needsTransaction() {
+		return r.orm.Transaction(func(tx interfaces.ORM) error {
+			return r.in(tx).Delete(entity)
+		})
+	}
+
+	dialect, err := r.writeDialect(entityValue)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+
+	original, err := r.auditOriginal(entity, entityValue)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+
 	query := fmt.Sprintf("DELETE FROM %s WHERE %s = %s",
-		r.metadata.TableName, r.metadata.PrimaryKey, r.orm.GetDialect().GetPlaceholder(0))
+		r.metadata.TableName, r.metadata.PrimaryKey, dialect.GetPlaceholder(0))
+	args := []interface{}{idField.Interface()}
 
-	_, err := r.orm.GetDialect().Exec(query, idField.Interface())
+	// Records of other tenants are left alone
+	tenant, tenantArgs, err := r.tenantCondition(dialect, len(args))
 	if err != nil {
 		return fmt.Errorf("failed to delete entity: %w", err)
 	}
+	if tenant != "" {
+		query += " AND " + tenant
+		args = append(args, tenantArgs...)
+	}
 
-	return nil
+	// Refuse to delete a record changed since it was loaded
+	var version int64
+	if r.metadata.Version != "" {
+		if _, version, err = r.versionField(entityValue); err != nil {
+			return fmt.Errorf("failed to delete entity: %w", err)
+		}
+		query += fmt.Sprintf(" AND %s = %s", r.metadata.Version, dialect.GetPlaceholder(len(args)))
+		args = append(args, version)
+	}
+
+	result, err := dialect.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+	if r.metadata.Version != "" {
+		if err := r.checkStale(result, idField.Interface(), version); err != nil {
+			return err
+		}
+	}
+	if err := r.checkTenantMatch(result, idField.Interface()); err != nil {
+		return err
+	}
+	tracking.Forget(entity)
+
+	audited, err := r.auditedChange(result)
+	if err != nil {
+		return err
+	}
+	if audited {
+		if err := r.audit(dialect, events.Deleted, entityValue, original, nil); err != nil {
+			return err
+		}
+	}
+	if err := r.invalidateCache(); err != nil {
+		return err
+	}
+	return r.dispatch(events.Deleted, entity)
 }
 
 // DeleteBy deletes records by criteria
@@ -85,24 +154,41 @@ func (r *RepositoryImpl) DeleteBy(criteria map[string]interface{}) error {
 	if r.metadata == nil {
 		return fmt.Errorf("metadata not available")
 	}
+	if err := r.refuseUnaudited("DeleteBy"); err != nil {
+		return err
+	}
 
 	var conditions []string
 	var args []interface{}
+	var where []interfaces.WhereCondition
 
 	for field, value := range criteria {
 		conditions = append(conditions, fmt.Sprintf("%s = %s", field, r.orm.GetDialect().GetPlaceholder(len(args))))
 		args = append(args, value)
+		where = append(where, interfaces.WhereCondition{Field: field, Operator: "=", Value: value})
+	}
+
+	tenant, tenantArgs, err := r.tenantCondition(r.orm.GetDialect(), len(args))
+	if err != nil {
+		return fmt.Errorf("failed to delete records by criteria: %w", err)
+	}
+	if tenant != "" {
+		conditions = append(conditions, tenant)
+		args = append(args, tenantArgs...)
 	}
 
 	whereClause := strings.Join(conditions, " AND ")
 	query := fmt.Sprintf("DELETE FROM %s WHERE %s", r.metadata.TableName, whereClause)
 
-	_, err := r.orm.GetDialect().Exec(query, args...)
+	dialects, err := r.tableDialects(r.metadata.TableName, where)
 	if err != nil {
 		return fmt.Errorf("failed to delete records by criteria: %w", err)
 	}
+	if err := execOn(dialects, query, args...); err != nil {
+		return fmt.Errorf("failed to delete records by criteria: %w", err)
+	}
 
-	return nil
+	return r.invalidateCache()
 }
 
 // findFieldByColumnName finds a struct field by its database column name
@@ -142,11 +228,38 @@ func (r *RepositoryImpl) findFieldByColumnName(entityValue reflect.Value, column
 
 // insert inserts a new entity
 func (r *RepositoryImpl) insert(entity interface{}) error {
+	if r.needsTransaction() {
+		return r.orm.Transaction(func(tx interfaces.ORM) error {
+			return r.in(tx).insert(entity)
+		})
+	}
+
 	entityValue := reflect.ValueOf(entity)
 	if entityValue.Kind() == reflect.Ptr {
 		entityValue = entityValue.Elem()
 	}
 
+	// The tenant is set first since it may also be the shard key
+	if err := r.injectTenant(entityValue); err != nil {
+		return fmt.Errorf("failed to insert entity: %w", err)
+	}
+
+	dialect, err := r.writeDialect(entityValue)
+	if err != nil {
+		return fmt.Errorf("failed to insert entity: %w", err)
+	}
+
+	// New records start at version 1
+	if r.metadata.Version != "" {
+		field, version, err := r.versionField(entityValue)
+		if err != nil {
+			return fmt.Errorf("failed to insert entity: %w", err)
+		}
+		if version == 0 {
+			setVersion(field, 1)
+		}
+	}
+
 	var columns []string
 	var values []interface{}
 	var placeholders []string
@@ -163,26 +276,34 @@ func (r *RepositoryImpl) insert(entity interface{}) error {
 			autoIncField = field
 			continue
 		}
-		columns = append(columns, column.Name)
-		values = append(values, field.Interface())
-		placeholders = append(placeholders, r.orm.GetDialect().GetPlaceholder(len(placeholders)))
+		names, persisted, err := r.persistedValues(column.Name, field)
+		if err != nil {
+			return fmt.Errorf("failed to insert entity: %w", err)
+		}
+		for i, name := range names {
+			columns = append(columns, name)
+			values = append(values, persisted[i])
+			placeholders = append(placeholders, dialect.GetPlaceholder(len(placeholders)))
+		}
 	}
 
 	var query string
 	var result interface{}
-	var err error
 
-	dialectName := strings.ToLower(reflect.TypeOf(r.orm.GetDialect()).String())
-	if strings.Contains(dialectName, "postgres") {
+	if r.isPostgres() {
 		// Use RETURNING for Postgres
 		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
 			r.metadata.TableName,
 			strings.Join(columns, ", "),
 			strings.Join(placeholders, ", "),
 			autoIncCol)
-		row := r.orm.GetDialect().QueryRow(query, values...)
+		row := dialect.QueryRow(query, values...)
 		var lastID int64
-		err = row.Scan(&lastID)
+		if row == nil {
+			err = fmt.Errorf("no row returned")
+		} else {
+			err = r.translateError(dialect, row.Scan(&lastID))
+		}
 		if err == nil && autoIncField.IsValid() && autoIncField.CanSet() {
 			autoIncField.SetInt(lastID)
 		}
@@ -192,7 +313,7 @@ func (r *RepositoryImpl) insert(entity interface{}) error {
 			r.metadata.TableName,
 			strings.Join(columns, ", "),
 			strings.Join(placeholders, ", "))
-		result, err = r.orm.GetDialect().Exec(query, values...)
+		result, err = dialect.Exec(query, values...)
 		if err == nil && autoIncField.IsValid() && autoIncField.CanSet() {
 			if res, ok := result.(interface{ LastInsertId() (int64, error) }); ok {
 				lastID, idErr := res.LastInsertId()
@@ -207,26 +328,75 @@ func (r *RepositoryImpl) insert(entity interface{}) error {
 		return fmt.Errorf("failed to insert entity: %w", err)
 	}
 
-	return nil
+	if err := r.audit(dialect, events.Created, entityValue, nil, r.columnValues(entityValue)); err != nil {
+		return err
+	}
+	if err := r.invalidateCache(); err != nil {
+		return err
+	}
+	return r.dispatch(events.Created, entity)
 }
 
 // update updates an existing entity
 func (r *RepositoryImpl) update(entity interface{}) error {
+	return r.updateAs(entity, events.Updated)
+}
+
+// updateAs updates an existing entity and publishes the update as the given event, since
+// soft deletes and restores are updates too
+func (r *RepositoryImpl) updateAs(entity interface{}, event string) error {
+	if r.needsTransaction() {
+		return r.orm.Transaction(func(tx interfaces.ORM) error {
+			return r.in(tx).updateAs(entity, event)
+		})
+	}
+
 	entityValue := reflect.ValueOf(entity)
 	if entityValue.Kind() == reflect.Ptr {
 		entityValue = entityValue.Elem()
 	}
 
+	dialect, err := r.writeDialect(entityValue)
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+
+	// Scoped updates never move a record to another tenant
+	_, tenantScoped, err := r.tenantScope()
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+
+	// Tracked entities only write the columns modified since they were loaded
+	var changes interfaces.Changes
+	original, tracked := tracking.Original(entity)
+	if tracked {
+		changes = tracking.Diff(original, r.columnValues(entityValue))
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+
+	before, err := r.auditOriginal(entity, entityValue)
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+
 	var sets []string
 	var values []interface{}
+	written := make(map[string]bool)
 
 	for _, column := range r.metadata.Columns {
+		if tracked && !changes.IsDirty(column.Name) {
+			continue
+		}
+
 		// Find field by column name
 		field := r.findFieldByColumnName(entityValue, column.Name)
 
 		// Handle soft delete column specially: include even if nil to allow setting NULL
 		if column.Name == r.metadata.DeletedAt {
-			sets = append(sets, fmt.Sprintf("%s = %s", column.Name, r.orm.GetDialect().GetPlaceholder(len(values))))
+			sets = append(sets, fmt.Sprintf("%s = %s", column.Name, dialect.GetPlaceholder(len(values))))
 
 			if field.IsValid() {
 				if field.Kind() == reflect.Ptr {
@@ -241,20 +411,52 @@ func (r *RepositoryImpl) update(entity interface{}) error {
 			} else {
 				values = append(values, nil)
 			}
+			written[column.Name] = true
 			continue
 		}
 
-		if !field.IsValid() || (field.Kind() == reflect.Ptr && field.IsNil()) {
-			continue // skip unset or nil fields
+		if !field.IsValid() {
+			continue
 		}
 
-		// Skip primary key for update
-		if column.Name == r.metadata.PrimaryKey {
+		// Skip primary key for update, and the version which is bumped below
+		if column.Name == r.metadata.PrimaryKey || column.Name == r.metadata.Version {
+			continue
+		}
+		if tenantScoped && column.Name == r.metadata.Tenant {
 			continue
 		}
 
-		sets = append(sets, fmt.Sprintf("%s = %s", column.Name, r.orm.GetDialect().GetPlaceholder(len(values))))
-		values = append(values, field.Interface())
+		// Nil fields are unset on untracked entities, but cleared since loading on tracked ones
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			if !tracked {
+				continue
+			}
+			names := []string{column.Name}
+			if index := r.metadata.Encrypted[column.Name]; index != "" {
+				names = append(names, index)
+			}
+			for _, name := range names {
+				sets = append(sets, fmt.Sprintf("%s = %s", name, dialect.GetPlaceholder(len(values))))
+				values = append(values, nil)
+			}
+			written[column.Name] = true
+			continue
+		}
+
+		names, persisted, err := r.persistedValues(column.Name, field)
+		if err != nil {
+			return fmt.Errorf("failed to update entity: %w", err)
+		}
+		for i, name := range names {
+			sets = append(sets, fmt.Sprintf("%s = %s", name, dialect.GetPlaceholder(len(values))))
+			values = append(values, persisted[i])
+		}
+		written[column.Name] = true
+	}
+	if len(sets) == 0 {
+		// Only columns an update never writes changed, such as the primary key
+		return nil
 	}
 
 	// Add WHERE condition for primary key
@@ -270,22 +472,184 @@ func (r *RepositoryImpl) update(entity interface{}) error {
 	if !idField.IsValid() {
 		return fmt.Errorf("primary key field %s not found", r.metadata.PrimaryKey)
 	}
+
+	// Bump the version, only matching the row if nobody else bumped it first
+	var versionValue reflect.Value
+	var version int64
+	if r.metadata.Version != "" {
+		if versionValue, version, err = r.versionField(entityValue); err != nil {
+			return fmt.Errorf("failed to update entity: %w", err)
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", r.metadata.Version, dialect.GetPlaceholder(len(values))))
+		values = append(values, version+1)
+		written[r.metadata.Version] = true
+	}
+
+	where := fmt.Sprintf("%s = %s", r.metadata.PrimaryKey, dialect.GetPlaceholder(len(values)))
 	values = append(values, idField.Interface())
+	if r.metadata.Version != "" {
+		where += fmt.Sprintf(" AND %s = %s", r.metadata.Version, dialect.GetPlaceholder(len(values)))
+		values = append(values, version)
+	}
+	tenant, tenantArgs, err := r.tenantCondition(dialect, len(values))
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+	if tenant != "" {
+		where += " AND " + tenant
+		values = append(values, tenantArgs...)
+	}
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s",
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
 		r.metadata.TableName,
 		strings.Join(sets, ", "),
-		r.metadata.PrimaryKey,
-		r.orm.GetDialect().GetPlaceholder(len(values)))
+		where)
 
-	_, err := r.orm.GetDialect().Exec(query, values...)
+	result, err := dialect.Exec(query, values...)
 	if err != nil {
 		return fmt.Errorf("failed to update entity: %w", err)
 	}
+	if r.metadata.Version != "" {
+		if err := r.checkStale(result, idField.Interface(), version); err != nil {
+			return err
+		}
+		setVersion(versionValue, version+1)
+	}
+	if err := r.checkTenantMatch(result, idField.Interface()); err != nil {
+		return err
+	}
+	tracking.Refresh(entity, r.columnValues(entityValue))
+
+	// Only the written columns are compared, fields left unset keep their stored value
+	audited, err := r.auditedChange(result)
+	if err != nil {
+		return err
+	}
+	if audited {
+		current := r.columnValues(entityValue)
+		for column := range current {
+			if !written[column] {
+				delete(current, column)
+			}
+		}
+		if err := r.audit(dialect, event, entityValue, before, current); err != nil {
+			return err
+		}
+	}
+	if err := r.invalidateCache(); err != nil {
+		return err
+	}
+	return r.dispatch(event, entity)
+}
+
+// versionField returns the entity's optimistic locking field and its current value
+func (r *RepositoryImpl) versionField(entityValue reflect.Value) (reflect.Value, int64, error) {
+	field := r.findFieldByColumnName(entityValue, r.metadata.Version)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return field, field.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field, int64(field.Uint()), nil
+	case reflect.Invalid:
+		return field, 0, fmt.Errorf("version field %s not found", r.metadata.Version)
+	}
+	return field, 0, fmt.Errorf("version field %s must be an integer, got %s", r.metadata.Version, field.Type())
+}
+
+// setVersion stores version in an optimistic locking field
+func setVersion(field reflect.Value, version int64) {
+	if !field.CanSet() {
+		return
+	}
+	if field.CanInt() {
+		field.SetInt(version)
+	} else {
+		field.SetUint(uint64(version))
+	}
+}
+
+// checkStale returns ErrStaleObject when a versioned write matched no row
+func (r *RepositoryImpl) checkStale(result sql.Result, id interface{}, version int64) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check version of %s %v: %w", r.metadata.TableName, id, err)
+	}
+	if affected == 0 {
+		return &interfaces.ErrStaleObject{Table: r.metadata.TableName, ID: id, Version: version}
+	}
+	return nil
+}
+
+// writeDialect returns the dialect storing the entity: its shard when the table is sharded.
+// Read-only transactions are refused here since PostgreSQL inserts run through QueryRow.
+func (r *RepositoryImpl) writeDialect(entityValue reflect.Value) (interfaces.Dialect, error) {
+	if tx, ok := r.orm.(interface{ ReadOnly() bool }); ok && tx.ReadOnly() {
+		return nil, interfaces.ErrReadOnlyTransaction
+	}
+
+	router, ok := r.orm.(interface {
+		ShardKey(table string) string
+		ShardDialect(table string, key interface{}) (interfaces.Dialect, error)
+	})
+	if !ok {
+		return r.orm.GetDialect(), nil
+	}
+
+	column := router.ShardKey(r.metadata.TableName)
+	if column == "" {
+		return r.orm.GetDialect(), nil
+	}
+
+	field := r.findFieldByColumnName(entityValue, column)
+	if !field.IsValid() {
+		return nil, fmt.Errorf("shard key field %s not found", column)
+	}
+	return router.ShardDialect(r.metadata.TableName, field.Interface())
+}
+
+// tableDialects returns the dialects a statement on table restricted by where runs on: the
+// shards it routes to when the table is sharded, or else the ORM's dialect
+func (r *RepositoryImpl) tableDialects(table string, where []interfaces.WhereCondition) ([]interfaces.Dialect, error) {
+	if router, ok := r.orm.(interface {
+		ShardDialects(table string, where []interfaces.WhereCondition) ([]interfaces.Dialect, error)
+	}); ok {
+		shards, err := router.ShardDialects(table, where)
+		if err != nil {
+			return nil, err
+		}
+		if shards != nil {
+			return shards, nil
+		}
+	}
+	return []interfaces.Dialect{r.orm.GetDialect()}, nil
+}
 
+// execOn runs a statement on every dialect, such as the shards returned by tableDialects
+func execOn(dialects []interfaces.Dialect, query string, args ...interface{}) error {
+	for _, dialect := range dialects {
+		if _, err := dialect.Exec(query, args...); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// translateError maps a driver error to the ORM's typed errors when the dialect, or a
+// dialect it wraps, knows how to
+func (r *RepositoryImpl) translateError(dialect interfaces.Dialect, err error) error {
+	for dialect != nil && err != nil {
+		if translator, ok := dialect.(interface{ TranslateError(err error) error }); ok {
+			return translator.TranslateError(err)
+		}
+		wrapper, ok := dialect.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			break
+		}
+		dialect = wrapper.Unwrap()
+	}
+	return err
+}
+
 // mapToStruct maps a database result to a struct
 func (r *RepositoryImpl) mapToStruct(result map[string]interface{}) (interface{}, error) {
 	if r.metadata == nil {
@@ -309,6 +673,25 @@ func (r *RepositoryImpl) mapToStruct(result map[string]interface{}) (interface{}
 			continue
 		}
 
+		if cast, ok := r.metadata.Casts[column.Name]; ok {
+			casted, err := casts.Get(cast, value, field.Type())
+			if err != nil {
+				return nil, fmt.Errorf("failed to cast field %s: %w", column.Name, err)
+			}
+			if err := assignValue(field, casted); err != nil {
+				return nil, fmt.Errorf("failed to set field %s: %w", column.Name, err)
+			}
+			continue
+		}
+
+		// Decrypted values arrive as text whatever the field type
+		if _, ok := r.metadata.Encrypted[column.Name]; ok {
+			if err := assignValue(field, value); err != nil {
+				return nil, fmt.Errorf("failed to set field %s: %w", column.Name, err)
+			}
+			continue
+		}
+
 		if err := setFieldValue(field, value); err != nil {
 			return nil, fmt.Errorf("failed to set field %s: %w", column.Name, err)
 		}
@@ -317,6 +700,114 @@ func (r *RepositoryImpl) mapToStruct(result map[string]interface{}) (interface{}
 	return entity, nil
 }
 
+// load maps a database result to a tracked entity
+func (r *RepositoryImpl) load(result map[string]interface{}) (interface{}, error) {
+	entity, err := r.mapToStruct(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load record: %w", err)
+	}
+	if err := r.executeHooks("AfterFind", entity); err != nil {
+		return nil, err
+	}
+	tracking.Track(entity, r.columnValues(reflect.ValueOf(entity).Elem()))
+	return entity, nil
+}
+
+// needsTransaction reports whether a write must open a transaction so its side effects
+// commit or roll back with it: the audit entry of an auditable model, and the changes of
+// synchronous listeners, whose errors undo the write. Sharded tables cannot run in a
+// transaction, so their listeners' errors do not undo the write.
+func (r *RepositoryImpl) needsTransaction() bool {
+	tx, ok := r.orm.(interface{ InTransaction() bool })
+	if !ok || tx.InTransaction() {
+		return false
+	}
+	if router, ok := r.orm.(interface{ ShardKey(table string) string }); ok && router.ShardKey(r.metadata.TableName) != "" {
+		return false
+	}
+	if r.metadata.Auditable || len(r.metadata.Events) > 0 {
+		return true
+	}
+	source, ok := r.orm.(interface{ Events() *events.Dispatcher })
+	return ok && source.Events() != nil && source.Events().HasSyncListeners(r.model)
+}
+
+// dispatch publishes a model event to the model's Events listeners and to the ORM's
+// dispatcher
+func (r *RepositoryImpl) dispatch(eventType string, entity interface{}) error {
+	for _, listener := range r.metadata.Events[eventType] {
+		if err := listener(entity); err != nil {
+			return fmt.Errorf("%s listener failed: %w", eventType, err)
+		}
+	}
+
+	source, ok := r.orm.(interface{ Events() *events.Dispatcher })
+	if !ok || source.Events() == nil {
+		return nil
+	}
+
+	var id interface{}
+	if field := r.findFieldByColumnName(reflect.Indirect(reflect.ValueOf(entity)), r.metadata.PrimaryKey); field.IsValid() {
+		id = field.Interface()
+	}
+	return source.Events().Dispatch(r.orm, events.Event{
+		Type:    eventType,
+		Table:   r.metadata.TableName,
+		ID:      id,
+		Entity:  entity,
+		Context: r.orm.Context(),
+	})
+}
+
+// persistedValues returns the columns and values written for a field: its value,
+// converted by its cast and encrypted if need be, followed by its blind index if any
+func (r *RepositoryImpl) persistedValues(column string, field reflect.Value) ([]string, []interface{}, error) {
+	value := field.Interface()
+	if cast, ok := r.metadata.Casts[column]; ok {
+		casted, err := casts.Set(cast, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to cast %s: %w", column, err)
+		}
+		value = casted
+	}
+
+	index, encrypted := r.metadata.Encrypted[column]
+	if !encrypted {
+		return []string{column}, []interface{}{value}, nil
+	}
+
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr {
+		value = nil
+		if !v.IsNil() {
+			value = v.Elem().Interface()
+		}
+	}
+	aad := encryption.AAD(r.metadata.TableName, column)
+	ciphertext, err := encryption.EncryptValue(value, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt %s: %w", column, err)
+	}
+	if index == "" {
+		return []string{column}, []interface{}{ciphertext}, nil
+	}
+	hash, err := encryption.IndexValue(value, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to index %s: %w", column, err)
+	}
+	return []string{column, index}, []interface{}{ciphertext, hash}, nil
+}
+
+// columnValues returns a snapshot of the entity's column values
+func (r *RepositoryImpl) columnValues(entityValue reflect.Value) map[string]interface{} {
+	values := make(map[string]interface{}, len(r.metadata.Columns))
+	for _, column := range r.metadata.Columns {
+		if field := r.findFieldByColumnName(entityValue, column.Name); field.IsValid() {
+			values[column.Name] = tracking.Value(field)
+		}
+	}
+	return values
+}
+
 // isZeroValue checks if a reflect.Value is a zero value
 func isZeroValue(v reflect.Value) bool {
 	switch v.Kind() {
@@ -400,6 +891,12 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 		} else {
 			return fmt.Errorf("cannot convert %v to bool", value)
 		}
+	case reflect.Ptr:
+		elem := reflect.New(fieldType.Elem())
+		if err := setFieldValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
 	default:
 		return fmt.Errorf("unsupported field type: %s", fieldType)
 	}
@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ESGI-M2/GO/orm/core/events"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tenancy"
+	"github.com/ESGI-M2/GO/orm/core/tracking"
 )
 
 // RepositoryImpl implements the Repository interface
@@ -41,9 +44,7 @@ func (r *RepositoryImpl) FindWithRelations(id interface{}, relations ...string)
 
 	// Add relations
 	for _, relation := range relations {
-		query = query.With(relation, func(q interfaces.QueryBuilder) interfaces.QueryBuilder {
-			return q
-		})
+		query = query.With(relation, nil)
 	}
 
 	result, err := query.FindOne()
@@ -69,9 +70,7 @@ func (r *RepositoryImpl) FindAllWithRelations(relations ...string) ([]interface{
 
 	// Add relations
 	for _, relation := range relations {
-		query = query.With(relation, func(q interfaces.QueryBuilder) interfaces.QueryBuilder {
-			return q
-		})
+		query = query.With(relation, nil)
 	}
 
 	results, err := query.Find()
@@ -106,9 +105,7 @@ func (r *RepositoryImpl) FindByWithRelations(criteria map[string]interface{}, re
 
 	// Add relations
 	for _, relation := range relations {
-		query = query.With(relation, func(q interfaces.QueryBuilder) interfaces.QueryBuilder {
-			return q
-		})
+		query = query.With(relation, nil)
 	}
 
 	results, err := query.Find()
@@ -133,6 +130,56 @@ func (r *RepositoryImpl) FindOneBy(criteria map[string]interface{}) (interface{}
 	return result, nil
 }
 
+// Load finds a record by primary key and returns it as a pointer to the model struct,
+// tracked so that saving it only writes the columns modified since
+func (r *RepositoryImpl) Load(id interface{}) (interface{}, error) {
+	if r.metadata == nil {
+		return nil, fmt.Errorf("metadata not available")
+	}
+
+	result, err := r.orm.Query(r.model).Where(r.metadata.PrimaryKey, "=", id).FindOne()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load record: %w", err)
+	}
+	return r.load(result)
+}
+
+// LoadBy finds records by criteria and returns them as tracked pointers to the model struct
+func (r *RepositoryImpl) LoadBy(criteria map[string]interface{}) ([]interface{}, error) {
+	if r.metadata == nil {
+		return nil, fmt.Errorf("metadata not available")
+	}
+
+	query := r.orm.Query(r.model)
+	for field, value := range criteria {
+		query = query.Where(field, "=", value)
+	}
+	results, err := query.Find()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load records: %w", err)
+	}
+
+	entities := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		entity, err := r.load(result)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// Changes returns the columns of a loaded entity modified since it was loaded or last
+// saved. Entities not loaded through Load or LoadBy are not tracked and report nil.
+func (r *RepositoryImpl) Changes(entity interface{}) interfaces.Changes {
+	original, tracked := tracking.Original(entity)
+	if !tracked || r.metadata == nil {
+		return nil
+	}
+	return tracking.Diff(original, r.columnValues(reflect.Indirect(reflect.ValueOf(entity))))
+}
+
 // Create creates a new record
 func (r *RepositoryImpl) Create(entity interface{}) error {
 	// Execute before hooks
@@ -186,7 +233,11 @@ func (r *RepositoryImpl) SoftDeleteBy(criteria map[string]interface{}) error {
 	}
 
 	for _, result := range results {
-		if err := r.SoftDelete(result); err != nil {
+		entity, err := r.mapToStruct(result)
+		if err != nil {
+			return err
+		}
+		if err := r.SoftDelete(entity); err != nil {
 			return err
 		}
 	}
@@ -215,6 +266,13 @@ func (r *RepositoryImpl) BatchCreate(entities []interface{}) error {
 		}
 	}
 
+	// Validate every record before writing any
+	for i, entity := range entities {
+		if err := r.Validate(entity); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+
 	// Batch create
 	for _, entity := range entities {
 		if err := r.orm.Repository(r.model).Save(entity); err != nil {
@@ -318,7 +376,11 @@ func (r *RepositoryImpl) SoftDelete(entity interface{}) error {
 	r.setDeletedAt(entity)
 
 	// Update the record
-	return r.Update(entity)
+	if err := r.updateAs(entity, events.Deleted); err != nil {
+		return err
+	}
+
+	return r.cascadeSoftDelete(entity, r.deletedAtValue(entity), false)
 }
 
 // Restore restores a soft-deleted record
@@ -327,11 +389,18 @@ func (r *RepositoryImpl) Restore(entity interface{}) error {
 		return fmt.Errorf("soft deletes not enabled for this model")
 	}
 
+	// Remember when the record was trashed so cascaded children can be matched
+	deletedAt := r.deletedAtValue(entity)
+
 	// Clear deleted_at timestamp
 	r.clearDeletedAt(entity)
 
 	// Update the record
-	return r.Update(entity)
+	if err := r.updateAs(entity, events.Restored); err != nil {
+		return err
+	}
+
+	return r.cascadeSoftDelete(entity, deletedAt, true)
 }
 
 // ForceDelete force deletes a record (ignores soft deletes)
@@ -360,7 +429,7 @@ func (r *RepositoryImpl) FindTrashed() ([]interface{}, error) {
 		return nil, fmt.Errorf("soft deletes not enabled for this model")
 	}
 
-	query := r.orm.Query(r.model).WhereNotNull(r.metadata.DeletedAt)
+	query := r.orm.Query(r.model).OnlyTrashed()
 	results, err := query.Find()
 	if err != nil {
 		return nil, fmt.Errorf("failed to find trashed records: %w", err)
@@ -374,7 +443,7 @@ func (r *RepositoryImpl) RestoreBy(criteria map[string]interface{}) error {
 		return fmt.Errorf("soft deletes not enabled for this model")
 	}
 
-	query := r.orm.Query(r.model).WhereNotNull(r.metadata.DeletedAt)
+	query := r.orm.Query(r.model).OnlyTrashed()
 
 	for field, value := range criteria {
 		query = query.Where(field, "=", value)
@@ -386,7 +455,11 @@ func (r *RepositoryImpl) RestoreBy(criteria map[string]interface{}) error {
 	}
 
 	for _, result := range results {
-		if err := r.Restore(result); err != nil {
+		entity, err := r.mapToStruct(result)
+		if err != nil {
+			return err
+		}
+		if err := r.Restore(entity); err != nil {
 			return err
 		}
 	}
@@ -492,12 +565,27 @@ func (r *RepositoryImpl) Increment(field string, amount interface{}) error {
 	if r.metadata == nil {
 		return fmt.Errorf("metadata not available")
 	}
+	if err := r.refuseUnaudited("Increment"); err != nil {
+		return err
+	}
 	query := fmt.Sprintf("UPDATE %s SET %s = %s + %s WHERE 1=1", r.metadata.TableName, field, field, r.orm.GetDialect().GetPlaceholder(0))
-	_, err := r.orm.GetDialect().Exec(query, amount)
+	args := []interface{}{amount}
+	tenant, tenantArgs, err := r.tenantCondition(r.orm.GetDialect(), len(args))
 	if err != nil {
 		return fmt.Errorf("failed to increment field: %w", err)
 	}
-	return nil
+	if tenant != "" {
+		query += " AND " + tenant
+		args = append(args, tenantArgs...)
+	}
+	dialects, err := r.tableDialects(r.metadata.TableName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to increment field: %w", err)
+	}
+	if err := execOn(dialects, query, args...); err != nil {
+		return fmt.Errorf("failed to increment field: %w", err)
+	}
+	return r.invalidateCache()
 }
 
 // Decrement decrements a field value for all records
@@ -505,16 +593,59 @@ func (r *RepositoryImpl) Decrement(field string, amount interface{}) error {
 	if r.metadata == nil {
 		return fmt.Errorf("metadata not available")
 	}
+	if err := r.refuseUnaudited("Decrement"); err != nil {
+		return err
+	}
 	query := fmt.Sprintf("UPDATE %s SET %s = %s - %s WHERE 1=1", r.metadata.TableName, field, field, r.orm.GetDialect().GetPlaceholder(0))
-	_, err := r.orm.GetDialect().Exec(query, amount)
+	args := []interface{}{amount}
+	tenant, tenantArgs, err := r.tenantCondition(r.orm.GetDialect(), len(args))
 	if err != nil {
 		return fmt.Errorf("failed to decrement field: %w", err)
 	}
-	return nil
+	if tenant != "" {
+		query += " AND " + tenant
+		args = append(args, tenantArgs...)
+	}
+	dialects, err := r.tableDialects(r.metadata.TableName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrement field: %w", err)
+	}
+	if err := execOn(dialects, query, args...); err != nil {
+		return fmt.Errorf("failed to decrement field: %w", err)
+	}
+	return r.invalidateCache()
 }
 
 // Helper methods
 
+// isPostgres reports whether the ORM runs on PostgreSQL, looking through dialect wrappers
+// such as query logging and transactions
+func (r *RepositoryImpl) isPostgres() bool {
+	d := r.orm.GetDialect()
+	for {
+		wrapper, ok := d.(interface{ Unwrap() interfaces.Dialect })
+		if !ok {
+			break
+		}
+		d = wrapper.Unwrap()
+	}
+	if d == nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(reflect.TypeOf(d).String()), "postgres")
+}
+
+// invalidateCache drops cached queries for the given tables, defaulting to the model's table
+func (r *RepositoryImpl) invalidateCache(tables ...string) error {
+	if len(tables) == 0 {
+		tables = []string{r.metadata.TableName}
+	}
+	if err := r.orm.InvalidateCache(tables...); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
 // executeHooks executes model hooks
 func (r *RepositoryImpl) executeHooks(hookType string, entity interface{}) error {
 	if r.metadata.Hooks == nil {
@@ -540,15 +671,31 @@ func (r *RepositoryImpl) executeHooks(hookType string, entity interface{}) error
 		hooks = r.metadata.Hooks.BeforeSave
 	case "AfterSave":
 		hooks = r.metadata.Hooks.AfterSave
+	case "AfterFind":
+		hooks = r.metadata.Hooks.AfterFind
 	}
+	method := r.metadata.Hooks.Methods[hookType]
 
-	for _, hook := range hooks {
-		if err := hook(entity); err != nil {
-			return fmt.Errorf("hook %s failed: %w", hookType, err)
+	// Method hooks get the ORM running the operation, transaction-scoped inside a transaction
+	orm := r.orm
+	run := func() error {
+		for _, hook := range hooks {
+			if err := hook(entity); err != nil {
+				return fmt.Errorf("hook %s failed: %w", hookType, err)
+			}
 		}
+		if method != nil {
+			if err := method(orm.Context(), orm, entity); err != nil {
+				return fmt.Errorf("hook %s failed: %w", hookType, err)
+			}
+		}
+		return nil
 	}
 
-	return nil
+	if r.metadata.Hooks.Deferred && strings.HasPrefix(hookType, "After") && (len(hooks) > 0 || method != nil) {
+		return r.orm.AfterCommit(run)
+	}
+	return run()
 }
 
 // setTimestamps sets created_at and updated_at timestamps
@@ -634,6 +781,179 @@ func (r *RepositoryImpl) clearDeletedAt(entity interface{}) {
 	}
 }
 
+// deletedAtValue returns the current value of the soft delete column, or nil when unset
+func (r *RepositoryImpl) deletedAtValue(entity interface{}) interface{} {
+	entityValue := reflect.ValueOf(entity)
+	if entityValue.Kind() == reflect.Ptr {
+		entityValue = entityValue.Elem()
+	}
+	if entityValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	field := r.findFieldByColumnName(entityValue, r.metadata.DeletedAt)
+	if !field.IsValid() || isZeroValue(field) {
+		return nil
+	}
+	if field.Kind() == reflect.Ptr {
+		return field.Elem().Interface()
+	}
+	return field.Interface()
+}
+
+// cascadeSoftDelete propagates a soft delete or restore to relations tagged with cascade.
+// On restore, only children trashed at the same time as the parent are brought back.
+func (r *RepositoryImpl) cascadeSoftDelete(entity interface{}, deletedAt interface{}, restore bool) error {
+	entityValue := reflect.ValueOf(entity)
+	if entityValue.Kind() == reflect.Ptr {
+		entityValue = entityValue.Elem()
+	}
+	if entityValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var parentID interface{}
+	for i := 0; i < entityValue.NumField(); i++ {
+		if strings.EqualFold(entityValue.Type().Field(i).Name, r.metadata.PrimaryKey) {
+			parentID = entityValue.Field(i).Interface()
+			break
+		}
+	}
+	if parentID == nil {
+		return nil
+	}
+
+	for name, relation := range r.metadata.Relations {
+		if !relation.Cascade || !cascadesToChildren(relation.Type) || relation.ForeignKey == "" {
+			continue
+		}
+
+		targetType := relation.TargetModel
+		for targetType.Kind() == reflect.Slice || targetType.Kind() == reflect.Ptr {
+			targetType = targetType.Elem()
+		}
+
+		target, err := r.orm.GetMetadata(reflect.New(targetType).Interface())
+		if err != nil {
+			return fmt.Errorf("failed to cascade to relation %s: %w", name, err)
+		}
+		if !target.SoftDeletes {
+			continue
+		}
+		if target.Auditable {
+			if err := r.cascadeAudited(target, reflect.New(targetType).Interface(), relation.ForeignKey, parentID, deletedAt, restore); err != nil {
+				return fmt.Errorf("failed to cascade to relation %s: %w", name, err)
+			}
+			continue
+		}
+
+		dialect := r.orm.GetDialect()
+		var query string
+		var args []interface{}
+		switch {
+		case !restore:
+			query = fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s AND %s IS NULL",
+				target.TableName, target.DeletedAt, dialect.GetPlaceholder(0),
+				relation.ForeignKey, dialect.GetPlaceholder(1), target.DeletedAt)
+			args = []interface{}{deletedAt, parentID}
+		case deletedAt != nil:
+			query = fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s = %s AND %s = %s",
+				target.TableName, target.DeletedAt,
+				relation.ForeignKey, dialect.GetPlaceholder(0), target.DeletedAt, dialect.GetPlaceholder(1))
+			args = []interface{}{parentID, deletedAt}
+		default:
+			query = fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s = %s AND %s IS NOT NULL",
+				target.TableName, target.DeletedAt,
+				relation.ForeignKey, dialect.GetPlaceholder(0), target.DeletedAt)
+			args = []interface{}{parentID}
+		}
+		if target.Tenant != "" {
+			tenant, scoped, err := tenancy.Scope(r.orm.Context(), target.TableName)
+			if err != nil {
+				return fmt.Errorf("failed to cascade to relation %s: %w", name, err)
+			}
+			if scoped {
+				query += fmt.Sprintf(" AND %s = %s", target.Tenant, dialect.GetPlaceholder(len(args)))
+				args = append(args, tenant)
+			}
+		}
+
+		// Children of a sharded table are found on the shards their foreign key routes to
+		dialects, err := r.tableDialects(target.TableName, []interfaces.WhereCondition{
+			{Field: relation.ForeignKey, Operator: "=", Value: parentID},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to cascade to relation %s: %w", name, err)
+		}
+		if err := execOn(dialects, query, args...); err != nil {
+			return fmt.Errorf("failed to cascade to relation %s: %w", name, err)
+		}
+		if err := r.invalidateCache(target.TableName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cascadeAudited soft deletes or restores the children of an auditable model one by one,
+// so each of them is audited. Like the bulk cascade, children are trashed with the
+// parent's timestamp and only those trashed with the parent are restored.
+func (r *RepositoryImpl) cascadeAudited(target *interfaces.ModelMetadata, model interface{}, foreignKey string, parentID, deletedAt interface{}, restore bool) error {
+	child := &RepositoryImpl{orm: r.orm, metadata: target, model: model}
+
+	query := r.orm.Query(model).WithoutCache().Where(foreignKey, "=", parentID)
+	if restore {
+		query = query.OnlyTrashed()
+		if deletedAt != nil {
+			query = query.Where(target.DeletedAt, "=", deletedAt)
+		}
+	}
+	rows, err := query.Find()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		entity, err := child.mapToStruct(row)
+		if err != nil {
+			return err
+		}
+		if restore {
+			if err := child.Restore(entity); err != nil {
+				return err
+			}
+			continue
+		}
+
+		field := child.findFieldByColumnName(reflect.ValueOf(entity).Elem(), target.DeletedAt)
+		if !field.IsValid() {
+			return fmt.Errorf("soft delete field %s not found", target.DeletedAt)
+		}
+		if err := setFieldValue(field, deletedAt); err != nil {
+			return err
+		}
+		if err := child.updateAs(entity, events.Deleted); err != nil {
+			return err
+		}
+		if err := child.cascadeSoftDelete(entity, deletedAt, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cascadesToChildren reports whether a relation type owns the rows it points to
+func cascadesToChildren(relationType interfaces.RelationType) bool {
+	switch relationType {
+	case interfaces.OneToOne, interfaces.OneToMany, interfaces.HasOne, interfaces.HasMany,
+		interfaces.MorphOne, interfaces.MorphMany:
+		return true
+	default:
+		return false
+	}
+}
+
 // convertToInterface converts []map[string]interface{} to []interface{}
 func (r *RepositoryImpl) convertToInterface(data []map[string]interface{}) []interface{} {
 	result := make([]interface{}, len(data))
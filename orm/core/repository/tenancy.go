@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tenancy"
+	"github.com/ESGI-M2/GO/orm/core/tracking"
+)
+
+// tenantScope returns the tenant the repository's statements are restricted to, with
+// scoped false for models without a tenant column and contexts bypassing tenant scoping
+func (r *RepositoryImpl) tenantScope() (interface{}, bool, error) {
+	if r.metadata.Tenant == "" {
+		return nil, false, nil
+	}
+	return tenancy.Scope(r.orm.Context(), r.metadata.TableName)
+}
+
+// tenantCondition returns the condition restricting a statement to the context's tenant,
+// with its placeholder numbered index, and its argument. Both are empty when the
+// statement is not scoped.
+func (r *RepositoryImpl) tenantCondition(dialect interfaces.Dialect, index int) (string, []interface{}, error) {
+	tenant, scoped, err := r.tenantScope()
+	if err != nil || !scoped {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s = %s", r.metadata.Tenant, dialect.GetPlaceholder(index)), []interface{}{tenant}, nil
+}
+
+// checkTenantMatch returns ErrNotFound when a tenant-scoped write by primary key matched
+// no row: the record does not exist or belongs to another tenant. MySQL reports no
+// affected row for an update leaving the row unchanged, so an empty result is confirmed
+// by looking the record up before being refused.
+func (r *RepositoryImpl) checkTenantMatch(result sql.Result, id interface{}) error {
+	_, scoped, err := r.tenantScope()
+	if err != nil || !scoped {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check tenant of %s %v: %w", r.metadata.TableName, id, err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	return r.checkVisible(id)
+}
+
+// checkVisible returns ErrNotFound unless the record, trashed or not, is visible through
+// the context's tenant
+func (r *RepositoryImpl) checkVisible(id interface{}) error {
+	found, err := r.orm.Query(r.model).WithoutCache().WithTrashed().
+		Where(r.metadata.PrimaryKey, "=", id).Exists()
+	if err != nil {
+		return fmt.Errorf("failed to check tenant of %s %v: %w", r.metadata.TableName, id, err)
+	}
+	if !found {
+		return fmt.Errorf("%s %v: %w", r.metadata.TableName, id, interfaces.ErrNotFound)
+	}
+	return nil
+}
+
+// injectTenant sets the tenant column of a new entity to the context's tenant. An entity
+// already holding another tenant is refused.
+func (r *RepositoryImpl) injectTenant(entityValue reflect.Value) error {
+	tenant, scoped, err := r.tenantScope()
+	if err != nil || !scoped {
+		return err
+	}
+
+	field := r.findFieldByColumnName(entityValue, r.metadata.Tenant)
+	if !field.IsValid() {
+		return fmt.Errorf("tenant field %s not found", r.metadata.Tenant)
+	}
+	if isZeroValue(field) {
+		return setFieldValue(field, tenant)
+	}
+	if current := tracking.Value(field); fmt.Sprint(current) != fmt.Sprint(tenant) {
+		return fmt.Errorf("record belongs to tenant %v, not to the context's tenant %v", current, tenant)
+	}
+	return nil
+}
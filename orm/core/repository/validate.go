@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/validation"
+)
+
+// Validate checks the entity against the rules of its validate tags and returns the failed
+// ones as ValidationErrors, or nil when it is valid. Save runs it before any statement.
+func (r *RepositoryImpl) Validate(entity interface{}) error {
+	if r.metadata == nil {
+		return fmt.Errorf("metadata not available")
+	}
+	if len(r.metadata.Validation) == 0 {
+		return nil
+	}
+
+	entityValue := reflect.Indirect(reflect.ValueOf(entity))
+	if entityValue.Kind() != reflect.Struct {
+		return fmt.Errorf("validate requires a struct, got %T", entity)
+	}
+
+	// Rules are listed column by column, in field order
+	var failures interfaces.ValidationErrors
+	rules := r.metadata.Validation
+	for start := 0; start < len(rules); {
+		end := start + 1
+		for end < len(rules) && rules[end].Column == rules[start].Column {
+			end++
+		}
+
+		column := rules[start].Column
+		if field := r.findFieldByColumnName(entityValue, column); field.IsValid() {
+			columnFailures, err := validation.Check(column, field, rules[start:end])
+			if err != nil {
+				return err
+			}
+			failures = append(failures, columnFailures...)
+		}
+		start = end
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
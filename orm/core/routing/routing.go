@@ -0,0 +1,267 @@
+package routing
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Strategy selects the replica serving a read
+type Strategy string
+
+const (
+	// RoundRobin rotates reads across replicas
+	RoundRobin Strategy = "round_robin"
+	// LeastLatency sends reads to the replica with the lowest average latency, probing
+	// the others in turn every probeInterval reads
+	LeastLatency Strategy = "least_latency"
+)
+
+// ParseStrategy returns the strategy named s, round robin when s is empty
+func ParseStrategy(s string) (Strategy, error) {
+	switch strategy := Strategy(s); strategy {
+	case "":
+		return RoundRobin, nil
+	case RoundRobin, LeastLatency:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown replica strategy %q: expected %q or %q", s, RoundRobin, LeastLatency)
+	}
+}
+
+// latencyWeight is the weight of the latest sample in the moving average
+const latencyWeight = 0.2
+
+// probeInterval is how often, in reads, the least latency strategy sends a read to the
+// next replica in turn, so that slower replicas are measured again and win back reads
+// once they recover
+const probeInterval = 16
+
+// Dialect sends plain SELECTs to read replicas and everything else, including
+// transactions and locking reads, to the primary
+type Dialect struct {
+	interfaces.Dialect
+	newReplica func() interfaces.Dialect
+
+	mu       sync.RWMutex
+	replicas []*replica
+	strategy Strategy
+	next     atomic.Uint64
+}
+
+// replica is a read replica and its observed latency
+type replica struct {
+	dialect interfaces.Dialect
+	mu      sync.Mutex
+	latency time.Duration
+}
+
+// NewDialect creates a routing dialect around primary. On Connect, newReplica is called
+// once per configured replica; it may be nil when replicas are added with AddReplica.
+func NewDialect(primary interfaces.Dialect, newReplica func() interfaces.Dialect) *Dialect {
+	return &Dialect{
+		Dialect:    primary,
+		newReplica: newReplica,
+		strategy:   RoundRobin,
+	}
+}
+
+// Unwrap returns the primary dialect
+func (d *Dialect) Unwrap() interfaces.Dialect {
+	return d.Dialect
+}
+
+// Primary returns the primary dialect
+func (d *Dialect) Primary() interfaces.Dialect {
+	return d.Dialect
+}
+
+// AddReplica registers an already connected replica
+func (d *Dialect) AddReplica(dialect interfaces.Dialect) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.replicas = append(d.replicas, &replica{dialect: dialect})
+}
+
+// SetStrategy changes how replicas are selected
+func (d *Dialect) SetStrategy(strategy Strategy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.strategy = strategy
+}
+
+// Connect connects the primary, then one replica per entry of config.Replicas
+func (d *Dialect) Connect(config interfaces.ConnectionConfig) error {
+	var strategy Strategy
+	if config.ReplicaStrategy != "" {
+		var err error
+		if strategy, err = ParseStrategy(config.ReplicaStrategy); err != nil {
+			return err
+		}
+	}
+	if err := d.Dialect.Connect(config); err != nil {
+		return err
+	}
+
+	if strategy != "" {
+		d.SetStrategy(strategy)
+	}
+	if len(config.Replicas) == 0 {
+		return nil
+	}
+	if d.newReplica == nil {
+		return fmt.Errorf("replicas configured but no replica dialect constructor set")
+	}
+
+	for _, replicaConfig := range config.Replicas {
+		dialect := d.newReplica()
+		if err := dialect.Connect(ReplicaConnectionConfig(config, replicaConfig)); err != nil {
+			return fmt.Errorf("failed to connect to replica %s:%d: %w", replicaConfig.Host, replicaConfig.Port, err)
+		}
+		d.AddReplica(dialect)
+	}
+	return nil
+}
+
+// Close closes the replicas and the primary
+func (d *Dialect) Close() error {
+	d.mu.Lock()
+	replicas := d.replicas
+	d.replicas = nil
+	d.mu.Unlock()
+
+	for _, r := range replicas {
+		r.dialect.Close()
+	}
+	return d.Dialect.Close()
+}
+
+// Query sends reads to a replica and other statements to the primary
+func (d *Dialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	r := d.route(query)
+	if r == nil {
+		return d.Dialect.Query(query, args...)
+	}
+
+	start := time.Now()
+	rows, err := r.dialect.Query(query, args...)
+	r.observe(time.Since(start))
+	return rows, err
+}
+
+// QueryRow sends reads to a replica and other statements to the primary
+func (d *Dialect) QueryRow(query string, args ...interface{}) *sql.Row {
+	r := d.route(query)
+	if r == nil {
+		return d.Dialect.QueryRow(query, args...)
+	}
+
+	start := time.Now()
+	row := r.dialect.QueryRow(query, args...)
+	r.observe(time.Since(start))
+	return row
+}
+
+// route returns the replica serving query, or nil when it must run on the primary
+func (d *Dialect) route(query string) *replica {
+	if !IsRead(query) {
+		return nil
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(d.replicas) == 0 {
+		return nil
+	}
+	n := d.next.Add(1) - 1
+	if d.strategy == LeastLatency {
+		if n%probeInterval != probeInterval-1 {
+			return d.fastest()
+		}
+		n /= probeInterval
+	}
+	return d.replicas[n%uint64(len(d.replicas))]
+}
+
+// fastest returns the replica with the lowest average latency; unmeasured replicas go first
+func (d *Dialect) fastest() *replica {
+	best := d.replicas[0]
+	bestLatency := best.averageLatency()
+	for _, r := range d.replicas[1:] {
+		if latency := r.averageLatency(); latency < bestLatency {
+			best, bestLatency = r, latency
+		}
+	}
+	return best
+}
+
+// observe folds a latency sample into the moving average
+func (r *replica) observe(sample time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.latency == 0 {
+		r.latency = sample
+		return
+	}
+	r.latency = time.Duration(latencyWeight*float64(sample) + (1-latencyWeight)*float64(r.latency))
+}
+
+// averageLatency returns the moving average latency
+func (r *replica) averageLatency() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latency
+}
+
+// IsRead reports whether query is a plain read that a replica may serve.
+// Locking reads (FOR UPDATE, FOR SHARE, LOCK IN SHARE MODE) must run on the primary.
+func IsRead(query string) bool {
+	query = strings.TrimSpace(query)
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return false
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+
+	upper := strings.ToUpper(query)
+	if !strings.HasPrefix(upper, "SELECT") {
+		return false
+	}
+	for _, lock := range []string{"FOR UPDATE", "FOR SHARE", "FOR NO KEY UPDATE", "LOCK IN SHARE MODE"} {
+		if strings.Contains(upper, lock) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReplicaConnectionConfig builds a replica's connection settings, inheriting what it leaves empty from the primary
+func ReplicaConnectionConfig(primary interfaces.ConnectionConfig, replica interfaces.ReplicaConfig) interfaces.ConnectionConfig {
+	config := primary
+	config.Replicas = nil
+	config.ReplicaStrategy = ""
+
+	config.Host = replica.Host
+	if replica.Port != 0 {
+		config.Port = replica.Port
+	}
+	if replica.Username != "" {
+		config.Username = replica.Username
+	}
+	if replica.Password != "" {
+		config.Password = replica.Password
+	}
+	if replica.Database != "" {
+		config.Database = replica.Database
+	}
+	return config
+}
@@ -0,0 +1,273 @@
+package serialize
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ESGI-M2/GO/orm/core/casts"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/metadata"
+)
+
+// manager extracts model metadata from struct tags; the metadata manager is not safe for
+// concurrent use, hence the lock
+var (
+	mu      sync.Mutex
+	manager = metadata.NewManager()
+)
+
+// ToMap converts an entity to a map keyed by column name. Hidden columns are dropped,
+// visible ones restrict the output when any is declared, attributes named in Appends are
+// added from the entity's accessor methods, casts are applied and loaded relations are
+// converted the same way. A relation pointing back to an entity being converted, such as
+// a post's author whose posts are loaded, is left out.
+func ToMap(entity interface{}) (map[string]interface{}, error) {
+	path := make(map[visit]bool)
+	if value := reflect.ValueOf(entity); value.Kind() == reflect.Ptr && !value.IsNil() {
+		path[visitOf(value)] = true
+	}
+	return toMap(entity, path)
+}
+
+// visit identifies an entity, or a slice of them, by address
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func visitOf(value reflect.Value) visit {
+	return visit{ptr: value.Pointer(), typ: value.Type()}
+}
+
+// toMap converts an entity, path holding the pointers and slices of the relations
+// leading to it
+func toMap(entity interface{}, path map[visit]bool) (map[string]interface{}, error) {
+	value := reflect.ValueOf(entity)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, fmt.Errorf("cannot serialize a nil entity")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot serialize %T, expected a struct", entity)
+	}
+
+	meta, err := metadataFor(value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	for _, column := range meta.Columns {
+		if !shown(meta, column.Name) {
+			continue
+		}
+		field := fieldByColumn(value, column.Name)
+		if !field.IsValid() {
+			continue
+		}
+		fieldValue := fieldInterface(field)
+		if cast, ok := meta.Casts[column.Name]; ok {
+			if fieldValue, err = casts.Serialize(cast, fieldValue); err != nil {
+				return nil, fmt.Errorf("failed to cast %s: %w", column.Name, err)
+			}
+		}
+		result[column.Name] = fieldValue
+	}
+
+	for name := range meta.Relations {
+		key := strings.ToLower(name)
+		if !shown(meta, key) {
+			continue
+		}
+		related, loaded, err := relationValue(value.FieldByName(name), path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize relation %s: %w", name, err)
+		}
+		if loaded {
+			result[key] = related
+		}
+	}
+
+	for _, name := range meta.Appends {
+		appended, err := accessor(value, name)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = appended
+	}
+
+	return result, nil
+}
+
+// ToJSON encodes an entity, or a slice of entities, as JSON with the rules of ToMap
+func ToJSON(entity interface{}) ([]byte, error) {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	if value.Kind() != reflect.Slice {
+		data, err := ToMap(entity)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	}
+
+	list := make([]map[string]interface{}, value.Len())
+	for i := range list {
+		data, err := ToMap(value.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		list[i] = data
+	}
+	return json.Marshal(list)
+}
+
+// Marshaler wraps an entity so encoding/json serializes it with the rules of ToMap. A
+// model can implement json.Marshaler with it:
+//
+//	func (u User) MarshalJSON() ([]byte, error) { return serialize.Marshaler(u).MarshalJSON() }
+func Marshaler(entity interface{}) json.Marshaler {
+	return marshaler{entity: entity}
+}
+
+type marshaler struct {
+	entity interface{}
+}
+
+func (m marshaler) MarshalJSON() ([]byte, error) {
+	return ToJSON(m.entity)
+}
+
+// metadataFor returns the metadata of a model type
+func metadataFor(t reflect.Type) (*interfaces.ModelMetadata, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return manager.ExtractMetadata(reflect.New(t).Interface())
+}
+
+// shown reports whether a key survives the Hidden and Visible lists
+func shown(meta *interfaces.ModelMetadata, key string) bool {
+	if slices.Contains(meta.Hidden, key) {
+		return false
+	}
+	return len(meta.Visible) == 0 || slices.Contains(meta.Visible, key)
+}
+
+// fieldByColumn finds the struct field holding a column, by orm or db tag, then by name
+func fieldByColumn(value reflect.Value, column string) reflect.Value {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		for _, part := range strings.Split(field.Tag.Get(metadata.TagORM), ",") {
+			if strings.TrimSpace(part) == "column:"+column {
+				return value.Field(i)
+			}
+		}
+		if field.Tag.Get(metadata.TagDB) == column || strings.EqualFold(field.Name, column) {
+			return value.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// fieldInterface returns a field value, dereferencing pointers and mapping nil to nil
+func fieldInterface(field reflect.Value) interface{} {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	return field.Interface()
+}
+
+// relationValue converts a relation field; nil pointers and slices and zero structs were
+// not loaded, and back-references to an entity on path are left out
+func relationValue(field reflect.Value, path map[visit]bool) (interface{}, bool, error) {
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() || path[visitOf(field)] {
+			return nil, false, nil
+		}
+		path[visitOf(field)] = true
+		defer delete(path, visitOf(field))
+		related, err := toMap(field.Interface(), path)
+		return related, err == nil, err
+	case reflect.Struct:
+		if field.IsZero() {
+			return nil, false, nil
+		}
+		related, err := toMap(field.Interface(), path)
+		return related, err == nil, err
+	case reflect.Slice:
+		if field.IsNil() || path[visitOf(field)] {
+			return nil, false, nil
+		}
+		path[visitOf(field)] = true
+		defer delete(path, visitOf(field))
+
+		list := make([]map[string]interface{}, 0, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			item := field.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() || path[visitOf(item)] {
+					continue
+				}
+				path[visitOf(item)] = true
+			}
+			related, err := toMap(item.Interface(), path)
+			if item.Kind() == reflect.Ptr {
+				delete(path, visitOf(item))
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			list = append(list, related)
+		}
+		return list, true, nil
+	}
+	return nil, false, nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// accessor calls the method computing an appended attribute: "full_name" is read from
+// FullName(), which returns the value and optionally an error
+func accessor(value reflect.Value, name string) (interface{}, error) {
+	methodName := camelCase(name)
+
+	// Call through a pointer to a copy so pointer receivers are found too
+	receiver := reflect.New(value.Type())
+	receiver.Elem().Set(value)
+	method := receiver.MethodByName(methodName)
+	if !method.IsValid() {
+		return nil, fmt.Errorf("appended attribute %s needs a %s() method on %s", name, methodName, value.Type())
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() == 0 || methodType.NumOut() > 2 ||
+		methodType.NumOut() == 2 && methodType.Out(1) != errorType {
+		return nil, fmt.Errorf("method %s.%s must take no arguments and return a value and optionally an error", value.Type(), methodName)
+	}
+
+	results := method.Call(nil)
+	if len(results) == 2 && !results[1].IsNil() {
+		return nil, fmt.Errorf("appended attribute %s failed: %w", name, results[1].Interface().(error))
+	}
+	return results[0].Interface(), nil
+}
+
+// camelCase turns a snake_case attribute name into an exported method name
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
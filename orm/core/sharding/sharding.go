@@ -0,0 +1,305 @@
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// ShardFunc maps a shard-key value to a shard index in [0, shards)
+type ShardFunc func(key interface{}, shards int) (int, error)
+
+// Rule tells how a table is spread across shards
+type Rule struct {
+	Column string
+	Func   ShardFunc
+}
+
+// Hash spreads keys evenly across shards using FNV-1a over their string form
+func Hash() ShardFunc {
+	return func(key interface{}, shards int) (int, error) {
+		h := fnv.New32a()
+		fmt.Fprint(h, key)
+		return int(h.Sum32() % uint32(shards)), nil
+	}
+}
+
+// Range assigns integer keys to shards by ascending upper bounds: keys below bounds[0]
+// go to shard 0, keys below bounds[1] to shard 1, and the rest to shard len(bounds)
+func Range(bounds ...int64) ShardFunc {
+	return func(key interface{}, shards int) (int, error) {
+		value, err := toInt64(key)
+		if err != nil {
+			return 0, err
+		}
+
+		index := sort.Search(len(bounds), func(i int) bool { return value < bounds[i] })
+		if index >= shards {
+			return 0, fmt.Errorf("shard key %d is out of range for %d shards", value, shards)
+		}
+		return index, nil
+	}
+}
+
+// Dialect holds one dialect per shard. Sharded tables are routed by the ORM through
+// Locate and Route; every other statement runs on the first shard.
+type Dialect struct {
+	interfaces.Dialect
+	shards []interfaces.Dialect
+
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// NewDialect creates a sharding dialect over already connected shards, in shard-index order
+func NewDialect(shards ...interfaces.Dialect) *Dialect {
+	d := &Dialect{shards: shards, rules: make(map[string]Rule)}
+	if len(shards) > 0 {
+		d.Dialect = shards[0]
+	}
+	return d
+}
+
+// Unwrap returns the first shard, which holds the unsharded tables
+func (d *Dialect) Unwrap() interfaces.Dialect {
+	return d.Dialect
+}
+
+// Shards returns the shard dialects in index order
+func (d *Dialect) Shards() []interfaces.Dialect {
+	return append([]interfaces.Dialect(nil), d.shards...)
+}
+
+// ShardBy spreads table across the shards on column using fn
+func (d *Dialect) ShardBy(table, column string, fn ShardFunc) *Dialect {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules[table] = Rule{Column: column, Func: fn}
+	return d
+}
+
+// Rule returns the sharding rule of table, if any
+func (d *Dialect) Rule(table string) (Rule, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rule, ok := d.rules[table]
+	return rule, ok
+}
+
+// Locate returns the shard holding the row of table with the given shard-key value
+func (d *Dialect) Locate(table string, key interface{}) (interfaces.Dialect, error) {
+	rule, ok := d.Rule(table)
+	if !ok {
+		return nil, fmt.Errorf("table %s is not sharded", table)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("missing shard key %s for table %s", rule.Column, table)
+	}
+
+	index, err := rule.Func(key, len(d.shards))
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate shard for table %s: %w", table, err)
+	}
+	if index < 0 || index >= len(d.shards) {
+		return nil, fmt.Errorf("shard function returned %d for %d shards", index, len(d.shards))
+	}
+	return d.shards[index], nil
+}
+
+// Route returns the shards a query on table must visit: the single shard named by an
+// equality on the shard key, or every shard otherwise. It returns nil for unsharded tables.
+func (d *Dialect) Route(table string, where []interfaces.WhereCondition) ([]interfaces.Dialect, error) {
+	rule, ok := d.Rule(table)
+	if !ok {
+		return nil, nil
+	}
+
+	for _, condition := range where {
+		if condition.Raw || condition.Operator != "=" || condition.Value == nil {
+			continue
+		}
+		if unqualified(condition.Field) != rule.Column {
+			continue
+		}
+		shard, err := d.Locate(table, condition.Value)
+		if err != nil {
+			return nil, err
+		}
+		return []interfaces.Dialect{shard}, nil
+	}
+	return d.Shards(), nil
+}
+
+// Connect checks that every shard is reachable; shards are connected by their owner
+func (d *Dialect) Connect(config interfaces.ConnectionConfig) error {
+	if len(d.shards) == 0 {
+		return fmt.Errorf("no shards configured")
+	}
+	for i, shard := range d.shards {
+		if err := shard.Ping(); err != nil {
+			return fmt.Errorf("shard %d is not reachable: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Close closes every shard
+func (d *Dialect) Close() error {
+	var firstErr error
+	for _, shard := range d.shards {
+		if err := shard.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// CreateTable creates sharded tables on every shard and other tables on the first one
+func (d *Dialect) CreateTable(tableName string, columns []interfaces.Column) error {
+	if _, ok := d.Rule(tableName); !ok {
+		return d.Dialect.CreateTable(tableName, columns)
+	}
+	for i, shard := range d.shards {
+		if err := shard.CreateTable(tableName, columns); err != nil {
+			return fmt.Errorf("failed to create table %s on shard %d: %w", tableName, i, err)
+		}
+	}
+	return nil
+}
+
+// DropTable drops sharded tables from every shard and other tables from the first one
+func (d *Dialect) DropTable(tableName string) error {
+	if _, ok := d.Rule(tableName); !ok {
+		return d.Dialect.DropTable(tableName)
+	}
+	for i, shard := range d.shards {
+		if err := shard.DropTable(tableName); err != nil {
+			return fmt.Errorf("failed to drop table %s on shard %d: %w", tableName, i, err)
+		}
+	}
+	return nil
+}
+
+// Merge combines rows gathered from several shards: it sorts them by orderBy, then applies
+// offset and limit (0 meaning no limit). Each shard must have been queried for its first
+// offset+limit rows in the same order.
+func Merge(results [][]map[string]interface{}, orderBy []interfaces.OrderBy, offset, limit int) []map[string]interface{} {
+	var merged []map[string]interface{}
+	for _, rows := range results {
+		merged = append(merged, rows...)
+	}
+
+	if len(orderBy) > 0 {
+		sort.SliceStable(merged, func(i, j int) bool {
+			for _, order := range orderBy {
+				column := unqualified(order.Field)
+				cmp := compare(merged[i][column], merged[j][column])
+				if cmp == 0 {
+					continue
+				}
+				if strings.EqualFold(order.Direction, "DESC") {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	if offset >= len(merged) {
+		return []map[string]interface{}{}
+	}
+	merged = merged[offset:]
+	if limit > 0 && limit < len(merged) {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// compare orders two scanned values; NULLs sort first, as they do in MySQL
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if x, err := toFloat64(a); err == nil {
+		if y, err := toFloat64(b); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	return strings.Compare(toString(a), toString(b))
+}
+
+// unqualified strips a table qualifier from a column name
+func unqualified(column string) string {
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		return column[i+1:]
+	}
+	return column
+}
+
+// toInt64 converts an integer shard key
+func toInt64(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	default:
+		return 0, fmt.Errorf("range sharding needs an integer key, got %T", value)
+	}
+}
+
+// toFloat64 converts numeric values for comparison, including numbers the MySQL text
+// protocol returns as []byte
+func toFloat64(value interface{}) (float64, error) {
+	if b, ok := value.([]byte); ok {
+		return strconv.ParseFloat(string(b), 64)
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := toInt64(value)
+		return float64(i), err
+	default:
+		return 0, fmt.Errorf("not a number: %T", value)
+	}
+}
+
+// toString converts text values, including the []byte returned by drivers, for comparison
+func toString(value interface{}) string {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
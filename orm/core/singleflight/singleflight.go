@@ -0,0 +1,91 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Group collapses concurrent calls sharing a key into a single execution.
+// The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+
+	total        atomic.Int64
+	executions   atomic.Int64
+	deduplicated atomic.Int64
+}
+
+// call represents an in-flight or completed execution
+type call struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// Stats reports how many calls a Group received and how many it collapsed
+type Stats struct {
+	Calls        int64
+	Executions   int64
+	Deduplicated int64
+}
+
+// Do executes fn for key, unless an identical call is already in flight, in which case
+// it waits for that call and returns its result. shared reports whether the result was
+// handed to more than one caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.total.Add(1)
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		g.deduplicated.Add(1)
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.executions.Add(1)
+	g.run(key, c, fn)
+
+	return c.val, c.err, false
+}
+
+// run executes fn and releases the waiters, even if fn panics
+func (g *Group) run(key string, c *call, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("singleflight: call panicked: %v", r)
+			g.finish(key, c)
+			panic(r)
+		}
+		g.finish(key, c)
+	}()
+
+	c.val, c.err = fn()
+}
+
+// finish removes the call from the in-flight set and wakes the waiters
+func (g *Group) finish(key string, c *call) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	c.wg.Done()
+}
+
+// Stats returns a snapshot of the group's counters
+func (g *Group) Stats() Stats {
+	return Stats{
+		Calls:        g.total.Load(),
+		Executions:   g.executions.Load(),
+		Deduplicated: g.deduplicated.Load(),
+	}
+}
@@ -0,0 +1,56 @@
+package tenancy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+type tenantKey struct{}
+
+type bypassKey struct{}
+
+// WithTenant returns a context scoping the statements of ORMs using it, see ORM.WithContext,
+// to tenant on models with a tenant column
+func WithTenant(ctx context.Context, tenant interface{}) context.Context {
+	ctx = context.WithValue(ctx, bypassKey{}, false)
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// WithoutTenant returns a context lifting tenant scoping, for cross-tenant work such as
+// administration and migrations. Statements then see and write every tenant's rows.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Tenant returns the tenant carried by ctx
+func Tenant(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// Bypassed reports whether ctx lifts tenant scoping
+func Bypassed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	bypassed, _ := ctx.Value(bypassKey{}).(bool)
+	return bypassed
+}
+
+// Scope returns the tenant statements on a tenant-scoped table are restricted to, with
+// scoped false when ctx bypasses scoping. A context with neither a tenant nor a bypass
+// fails with ErrNoTenant rather than reaching every tenant's rows.
+func Scope(ctx context.Context, table string) (tenant interface{}, scoped bool, err error) {
+	if Bypassed(ctx) {
+		return nil, false, nil
+	}
+	if tenant, ok := Tenant(ctx); ok {
+		return tenant, true, nil
+	}
+	return nil, false, fmt.Errorf("%w for table %s", interfaces.ErrNoTenant, table)
+}
@@ -0,0 +1,114 @@
+package tracking
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// snapshots holds the column values of tracked entities as last read from or written to
+// the database, keyed by entity address. Keys are plain addresses so the store does not
+// keep entities alive; a finalizer drops the snapshot once the entity is collected.
+var (
+	mu        sync.Mutex
+	snapshots = make(map[uintptr]map[string]interface{})
+)
+
+// Track starts tracking an entity the ORM allocated, recording its column values.
+// Entities must not carry a finalizer of their own.
+func Track(entity interface{}, values map[string]interface{}) {
+	key, ok := address(entity)
+	if !ok {
+		return
+	}
+
+	mu.Lock()
+	_, tracked := snapshots[key]
+	snapshots[key] = values
+	mu.Unlock()
+
+	if !tracked {
+		runtime.SetFinalizer(entity, func(interface{}) {
+			mu.Lock()
+			delete(snapshots, key)
+			mu.Unlock()
+		})
+	}
+}
+
+// Refresh replaces the snapshot of a tracked entity after it was written; untracked
+// entities are left untracked
+func Refresh(entity interface{}, values map[string]interface{}) {
+	key, ok := address(entity)
+	if !ok {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, tracked := snapshots[key]; tracked {
+		snapshots[key] = values
+	}
+}
+
+// Original returns the snapshot of a tracked entity
+func Original(entity interface{}) (map[string]interface{}, bool) {
+	key, ok := address(entity)
+	if !ok {
+		return nil, false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	values, tracked := snapshots[key]
+	return values, tracked
+}
+
+// Forget stops tracking an entity, for instance once it is deleted
+func Forget(entity interface{}) {
+	key, ok := address(entity)
+	if !ok {
+		return
+	}
+
+	mu.Lock()
+	delete(snapshots, key)
+	mu.Unlock()
+}
+
+// Diff returns the columns whose current value differs from the original one
+func Diff(original, current map[string]interface{}) interfaces.Changes {
+	changes := interfaces.Changes{}
+	for column, value := range current {
+		if old, ok := original[column]; !ok || !reflect.DeepEqual(old, value) {
+			changes[column] = interfaces.Change{Old: old, New: value}
+		}
+	}
+	return changes
+}
+
+// Value returns a copy of a field value fit for a snapshot: pointers are replaced by the
+// value they point to and byte slices are copied, so in-place edits show up as changes
+func Value(field reflect.Value) interface{} {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8 && !field.IsNil() {
+		return append([]byte(nil), field.Bytes()...)
+	}
+	return field.Interface()
+}
+
+// address returns the address identifying a pointer entity
+func address(entity interface{}) (uintptr, bool) {
+	value := reflect.ValueOf(entity)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return 0, false
+	}
+	return value.Pointer(), true
+}
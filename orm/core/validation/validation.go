@@ -0,0 +1,204 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// Rule checks a value against the rule's parameter, the text after "=" in the tag. The
+// returned error's message describes the failure, as in "must be at least 18".
+type Rule func(value reflect.Value, param string) error
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{
+		"required": required,
+		"min":      minimum,
+		"max":      maximum,
+		"len":      length,
+		"email":    email,
+		"url":      link,
+		"oneof":    oneOf,
+	}
+)
+
+// Register adds a custom rule usable as `validate:"<name>"` or `validate:"<name>=<param>"`,
+// replacing any rule of that name
+func Register(name string, rule Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[name] = rule
+}
+
+// Parse parses a validate tag such as "required,max=255,email" into rules for column.
+// "omitempty" skips the following rules when the value is empty.
+func Parse(column, tag string) []interfaces.ValidationRule {
+	var parsed []interfaces.ValidationRule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		parsed = append(parsed, interfaces.ValidationRule{
+			Column: column,
+			Type:   strings.TrimSpace(name),
+			Value:  strings.TrimSpace(param),
+		})
+	}
+	return parsed
+}
+
+// Check runs rules against a column value and returns the failures. Nil pointers only fail
+// "required"; other values are checked dereferenced. An unknown rule is an error.
+func Check(column string, value reflect.Value, columnRules []interfaces.ValidationRule) ([]interfaces.FieldError, error) {
+	isNil := false
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			isNil = true
+			break
+		}
+		value = value.Elem()
+	}
+
+	var failures []interfaces.FieldError
+	for _, rule := range columnRules {
+		if rule.Type == "omitempty" {
+			if isNil || empty(value) {
+				break
+			}
+			continue
+		}
+
+		mu.RLock()
+		check, ok := rules[rule.Type]
+		mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown validation rule %q on %s", rule.Type, column)
+		}
+
+		var err error
+		switch {
+		case isNil && rule.Type == "required":
+			err = fmt.Errorf("is required")
+		case isNil:
+			continue
+		default:
+			err = check(value, fmt.Sprint(rule.Value))
+		}
+		if err == nil {
+			continue
+		}
+
+		message := err.Error()
+		if rule.Message != "" {
+			message = rule.Message
+		}
+		failures = append(failures, interfaces.FieldError{Field: column, Rule: rule.Type, Message: message})
+	}
+	return failures, nil
+}
+
+// empty reports whether a value is the zero value or an empty collection
+func empty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func required(value reflect.Value, _ string) error {
+	if empty(value) {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+// size returns the value compared by min, max and len: numbers themselves, the character
+// count of strings and the length of collections, with the unit used in messages
+func size(value reflect.Value) (float64, string, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), " items", true
+	}
+	return 0, "", false
+}
+
+// compare checks the size of value against param with ok
+func compare(value reflect.Value, param, rule, message string, ok func(size, limit float64) bool) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid %s parameter %q", rule, param)
+	}
+	n, unit, sized := size(value)
+	if !sized {
+		return fmt.Errorf("cannot be checked by %s", rule)
+	}
+	if !ok(n, limit) {
+		return fmt.Errorf("%s %s%s", message, param, unit)
+	}
+	return nil
+}
+
+func minimum(value reflect.Value, param string) error {
+	return compare(value, param, "min", "must be at least", func(n, limit float64) bool { return n >= limit })
+}
+
+func maximum(value reflect.Value, param string) error {
+	return compare(value, param, "max", "must be at most", func(n, limit float64) bool { return n <= limit })
+}
+
+func length(value reflect.Value, param string) error {
+	return compare(value, param, "len", "must be exactly", func(n, limit float64) bool { return n == limit })
+}
+
+func email(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("must be a string")
+	}
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return fmt.Errorf("must be a valid email address")
+	}
+	return nil
+}
+
+func link(value reflect.Value, _ string) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("must be a string")
+	}
+	parsed, err := url.ParseRequestURI(value.String())
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("must be a valid URL")
+	}
+	return nil
+}
+
+// oneOf accepts space separated values, as in `validate:"oneof=draft published"`
+func oneOf(value reflect.Value, param string) error {
+	allowed := strings.Fields(param)
+	actual := fmt.Sprint(value.Interface())
+	for _, candidate := range allowed {
+		if candidate == actual {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+}
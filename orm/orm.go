@@ -1,9 +1,12 @@
 package orm
 
 import (
+	"encoding/json"
+
 	"github.com/ESGI-M2/GO/dialect"
 	"github.com/ESGI-M2/GO/orm/core/connection"
 	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/serialize"
 )
 
 // ORM provides the main interface for the ORM
@@ -36,6 +39,22 @@ func NewPostgresConnectionConfigFromEnv() ConnectionConfig {
 	return dialect.NewPostgresConnectionConfigFromEnv()
 }
 
+// ToMap converts an entity to a map honoring its hidden, visible, appended and cast columns,
+// including on loaded relations
+func ToMap(entity interface{}) (map[string]interface{}, error) {
+	return serialize.ToMap(entity)
+}
+
+// ToJSON encodes an entity, or a slice of entities, as JSON with the rules of ToMap
+func ToJSON(entity interface{}) ([]byte, error) {
+	return serialize.ToJSON(entity)
+}
+
+// Marshaler wraps an entity so encoding/json serializes it with the rules of ToMap
+func Marshaler(entity interface{}) json.Marshaler {
+	return serialize.Marshaler(entity)
+}
+
 // ConnectionConfig represents database connection configuration
 type ConnectionConfig = interfaces.ConnectionConfig
 
@@ -45,6 +64,15 @@ type QueryBuilder = interfaces.QueryBuilder
 // Repository represents a repository
 type Repository = interfaces.Repository
 
+// Changes lists the modified columns of an entity loaded through a repository
+type Changes = interfaces.Changes
+
+// Change is the original and current value of a modified column
+type Change = interfaces.Change
+
+// AuditEntry is a change recorded in the audit log of an auditable model
+type AuditEntry = interfaces.AuditEntry
+
 // Dialect represents a database dialect
 type Dialect = interfaces.Dialect
 
@@ -85,3 +113,33 @@ const (
 	ManyToOne  = interfaces.ManyToOne
 	ManyToMany = interfaces.ManyToMany
 )
+
+// Errors returned by the ORM, to be matched with errors.Is and errors.As
+var (
+	ErrNotFound            = interfaces.ErrNotFound
+	ErrDeadlock            = interfaces.ErrDeadlock
+	ErrConnection          = interfaces.ErrConnection
+	ErrReadOnlyTransaction = interfaces.ErrReadOnlyTransaction
+	ErrNoTenant            = interfaces.ErrNoTenant
+)
+
+// ErrUniqueViolation is returned when a write breaks a unique constraint
+type ErrUniqueViolation = interfaces.ErrUniqueViolation
+
+// ErrForeignKeyViolation is returned when a write breaks a foreign key constraint
+type ErrForeignKeyViolation = interfaces.ErrForeignKeyViolation
+
+// ErrNotNullViolation is returned when a write leaves a NOT NULL column empty
+type ErrNotNullViolation = interfaces.ErrNotNullViolation
+
+// ErrStaleObject is returned when a versioned update or delete lost a concurrent edit
+type ErrStaleObject = interfaces.ErrStaleObject
+
+// ErrMassAssignment is returned when Fill is given protected or unknown keys
+type ErrMassAssignment = interfaces.ErrMassAssignment
+
+// ValidationErrors lists the validation rules an entity failed before being saved
+type ValidationErrors = interfaces.ValidationErrors
+
+// FieldError describes one failed validation rule
+type FieldError = interfaces.FieldError
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+func TestAfterCommit_RunsOnlyOnCommit(t *testing.T) {
+	orm, _ := setupSavepointORM(t)
+	var calls []string
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		tx.AfterCommit(func() error { calls = append(calls, "commit"); return nil })
+		tx.AfterRollback(func() error { calls = append(calls, "rollback"); return nil })
+		if len(calls) != 0 {
+			t.Error("Callbacks should wait for the transaction to settle")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	failure := errors.New("failure")
+	err = orm.Transaction(func(tx interfaces.ORM) error {
+		tx.AfterCommit(func() error { calls = append(calls, "commit"); return nil })
+		tx.AfterRollback(func() error { calls = append(calls, "rollback"); return nil })
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the transaction error, got %v", err)
+	}
+
+	if expected := []string{"commit", "rollback"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestAfterCommit_SavepointsDeferToOutermostCommit(t *testing.T) {
+	orm, _ := setupSavepointORM(t)
+	var calls []string
+
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		outer.Transaction(func(released interfaces.ORM) error {
+			released.AfterCommit(func() error { calls = append(calls, "released"); return nil })
+			return nil
+		})
+		outer.Transaction(func(undone interfaces.ORM) error {
+			undone.AfterCommit(func() error { calls = append(calls, "undone"); return nil })
+			undone.AfterRollback(func() error { calls = append(calls, "savepoint rollback"); return nil })
+			return errors.New("undo")
+		})
+		if expected := []string{"savepoint rollback"}; !reflect.DeepEqual(calls, expected) {
+			t.Errorf("Only the savepoint rollback callback should have run, got %v", calls)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if expected := []string{"savepoint rollback", "released"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestAfterCommit_OutsideTransactionAndErrors(t *testing.T) {
+	orm, _ := setupSavepointORM(t)
+
+	ran := false
+	if err := orm.AfterCommit(func() error { ran = true; return nil }); err != nil || !ran {
+		t.Errorf("AfterCommit outside a transaction should run immediately, got ran=%v err=%v", ran, err)
+	}
+
+	failure := errors.New("mail server down")
+	second := false
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		tx.AfterCommit(func() error { return failure })
+		tx.AfterCommit(func() error { second = true; return nil })
+		return nil
+	})
+	if !errors.Is(err, failure) || !second {
+		t.Errorf("Callback errors should be reported after running every callback, got %v", err)
+	}
+}
+
+func TestAfterCommit_DeferredModelHooks(t *testing.T) {
+	orm, _ := setupSavepointORM(t)
+	if err := orm.RegisterModel(&QueryTestModel{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	metadata, _ := orm.GetMetadata(&QueryTestModel{})
+
+	var created []string
+	metadata.Hooks = &interfaces.ModelHooks{
+		AfterCreate: []func(interface{}) error{func(entity interface{}) error {
+			created = append(created, entity.(*QueryTestModel).Name)
+			return nil
+		}},
+		Deferred: true,
+	}
+	defer func() { metadata.Hooks = nil }()
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		tx.Repository(&QueryTestModel{}).BatchCreate([]interface{}{&QueryTestModel{Name: "kept"}})
+		if len(created) != 0 {
+			t.Error("Deferred hooks should not run before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	orm.Transaction(func(tx interfaces.ORM) error {
+		tx.Repository(&QueryTestModel{}).BatchCreate([]interface{}{&QueryTestModel{Name: "dropped"}})
+		return errors.New("rollback")
+	})
+
+	if expected := []string{"kept"}; !reflect.DeepEqual(created, expected) {
+		t.Errorf("Expected %v, got %v", expected, created)
+	}
+}
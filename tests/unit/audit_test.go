@@ -0,0 +1,278 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/audit"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type AuditTestAccount struct {
+	ID       int    `orm:"pk,auto,auditable"`
+	Name     string `orm:"column:name"`
+	Password string `orm:"column:password,sensitive"`
+}
+
+// auditResult is the result of statements run by auditTx
+type auditResult struct{ rows int64 }
+
+func (auditResult) LastInsertId() (int64, error)   { return 42, nil }
+func (r auditResult) RowsAffected() (int64, error) { return r.rows, nil }
+
+// auditTx is a transaction recording its statements, reading from a fixed table
+type auditTx struct {
+	db      *sql.DB
+	failOn  string
+	missing bool // statements match no row
+	execs   []string
+	args    [][]interface{}
+	ends    []string
+}
+
+func (tx *auditTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	tx.execs = append(tx.execs, query)
+	tx.args = append(tx.args, args)
+	if tx.failOn != "" && strings.Contains(query, tx.failOn) {
+		return nil, errors.New("disk full")
+	}
+	if tx.missing {
+		return auditResult{}, nil
+	}
+	return auditResult{rows: 1}, nil
+}
+
+func (tx *auditTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.db.Query(query, args...)
+}
+
+func (tx *auditTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.db.QueryRow(query, args...)
+}
+
+func (tx *auditTx) Commit() error   { tx.ends = append(tx.ends, "COMMIT"); return nil }
+func (tx *auditTx) Rollback() error { tx.ends = append(tx.ends, "ROLLBACK"); return nil }
+
+// auditDialect is a mock dialect handing out an auditTx and reading from its table
+type auditDialect struct {
+	*dialect.MockDialect
+	tx *auditTx
+}
+
+func (d *auditDialect) Begin() (interfaces.Transaction, error) { return d.tx, nil }
+func (d *auditDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
+	return d.tx, nil
+}
+func (d *auditDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.tx.db.Query(query, args...)
+}
+
+func setupAuditORM(t *testing.T, columns []string, rows ...[]driver.Value) (*connection.ORMImpl, *auditTx) {
+	db := sql.OpenDB(&tableConnector{columns: columns, rows: rows})
+	t.Cleanup(func() { db.Close() })
+
+	d := &auditDialect{MockDialect: dialect.NewMockDialect(), tx: &auditTx{db: db}}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&AuditTestAccount{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm, d.tx
+}
+
+// auditValues decodes the JSON values of an audit_log insert
+func auditValues(t *testing.T, value interface{}) map[string]interface{} {
+	t.Helper()
+	if value == nil {
+		return nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(value.(string)), &values); err != nil {
+		t.Fatalf("Invalid audit JSON %v: %v", value, err)
+	}
+	return values
+}
+
+func TestAudit_Metadata(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &AuditTestAccount{}, &EventTestPost{})
+
+	audited, _ := orm.GetMetadata(&AuditTestAccount{})
+	plain, _ := orm.GetMetadata(&EventTestPost{})
+	if !audited.Auditable || plain.Auditable {
+		t.Errorf("Expected only the tagged model to be auditable, got %v and %v", audited.Auditable, plain.Auditable)
+	}
+}
+
+func TestAudit_RecordsChangesInTheirTransaction(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "name", "password"}, []driver.Value{int64(42), "ada", "secret"})
+	repo := orm.WithContext(audit.WithActor(context.Background(), "alice")).Repository(&AuditTestAccount{})
+
+	account := &AuditTestAccount{Name: "ada", Password: "secret"}
+	if err := repo.Save(account); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	account.Name = "grace"
+	if err := repo.Update(account); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Delete(account); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(tx.ends, []string{"COMMIT", "COMMIT", "COMMIT"}) {
+		t.Fatalf("Expected each change to commit with its audit entry, got %v", tx.ends)
+	}
+	if len(tx.execs) != 6 {
+		t.Fatalf("Expected a change and an audit entry per operation, got %v", tx.execs)
+	}
+
+	expected := []struct {
+		action        string
+		before, after map[string]interface{}
+	}{
+		{"created", nil, map[string]interface{}{"id": 42.0, "name": "ada", "password": audit.Redacted}},
+		{"updated", map[string]interface{}{"name": "ada"}, map[string]interface{}{"name": "grace"}},
+		{"deleted", map[string]interface{}{"id": 42.0, "name": "ada", "password": audit.Redacted}, nil},
+	}
+	for i, want := range expected {
+		query, args := tx.execs[2*i+1], tx.args[2*i+1]
+		if !strings.HasPrefix(query, "INSERT INTO audit_log") {
+			t.Fatalf("Expected an audit entry, got %s", query)
+		}
+		if args[0] != "audittestaccount" || args[1] != "42" || args[2] != want.action || args[5] != "alice" {
+			t.Errorf("Unexpected audit entry %v", args)
+		}
+		if before := auditValues(t, args[3]); !reflect.DeepEqual(before, want.before) {
+			t.Errorf("Expected old values %v for %s, got %v", want.before, want.action, before)
+		}
+		if after := auditValues(t, args[4]); !reflect.DeepEqual(after, want.after) {
+			t.Errorf("Expected new values %v for %s, got %v", want.after, want.action, after)
+		}
+		if _, ok := args[6].(time.Time); !ok {
+			t.Errorf("Expected a timestamp, got %v", args[6])
+		}
+	}
+}
+
+func TestAudit_FailureRollsBackTheChange(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "name", "password"})
+	tx.failOn = "audit_log"
+
+	err := orm.Repository(&AuditTestAccount{}).Save(&AuditTestAccount{Name: "ada"})
+	if err == nil || !strings.Contains(err.Error(), "failed to audit created: disk full") {
+		t.Fatalf("Expected the audit failure, got %v", err)
+	}
+	if !reflect.DeepEqual(tx.ends, []string{"ROLLBACK"}) {
+		t.Errorf("Expected the insert to roll back, got %v", tx.ends)
+	}
+}
+
+func TestAudit_JoinsTheCurrentTransaction(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "name", "password"})
+
+	err := orm.Transaction(func(inner interfaces.ORM) error {
+		return inner.Repository(&AuditTestAccount{}).Save(&AuditTestAccount{Name: "ada"})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if len(tx.execs) != 2 || !reflect.DeepEqual(tx.ends, []string{"COMMIT"}) {
+		t.Errorf("Expected the change and its entry in one transaction, got %v %v", tx.execs, tx.ends)
+	}
+}
+
+func TestAudit_SkipsWritesMatchingNoRecord(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "name", "password"}, []driver.Value{int64(42), "ada", "secret"})
+	tx.missing = true
+
+	if err := orm.Repository(&AuditTestAccount{}).Update(&AuditTestAccount{ID: 42, Name: "grace"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(tx.execs) != 1 || !strings.HasPrefix(tx.execs[0], "UPDATE") {
+		t.Errorf("Expected no audit entry for an update matching no record, got %v", tx.execs)
+	}
+}
+
+func TestAudit_RefusesBulkWrites(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "name", "password"})
+	repo := orm.Repository(&AuditTestAccount{})
+
+	if err := repo.DeleteBy(map[string]interface{}{"name": "ada"}); err == nil || !strings.Contains(err.Error(), "cannot be audited") {
+		t.Errorf("Expected DeleteBy to be refused, got %v", err)
+	}
+	if err := repo.Increment("name", 1); err == nil || !strings.Contains(err.Error(), "cannot be audited") {
+		t.Errorf("Expected Increment to be refused, got %v", err)
+	}
+	if len(tx.execs) != 0 {
+		t.Errorf("Refused writes should not run, got %v", tx.execs)
+	}
+}
+
+type AuditTestFolder struct {
+	ID        int             `orm:"pk,auto"`
+	DeletedAt *time.Time      `orm:"column:deleted_at,soft"`
+	Notes     []AuditTestNote `orm:"relation:one_to_many,fk:folder_id,cascade"`
+}
+
+type AuditTestNote struct {
+	ID        int        `orm:"pk,auto,auditable"`
+	FolderID  int        `orm:"column:folder_id"`
+	DeletedAt *time.Time `orm:"column:deleted_at,soft"`
+}
+
+func TestAudit_CascadesRecordEachChild(t *testing.T) {
+	orm, tx := setupAuditORM(t, []string{"id", "folder_id", "deleted_at"}, []driver.Value{int64(5), int64(1), nil})
+	for _, model := range []interface{}{&AuditTestFolder{}, &AuditTestNote{}} {
+		if err := orm.RegisterModel(model); err != nil {
+			t.Fatalf("RegisterModel failed: %v", err)
+		}
+	}
+
+	if err := orm.Repository(&AuditTestFolder{}).SoftDelete(&AuditTestFolder{ID: 1}); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if len(tx.execs) != 2 || !strings.HasPrefix(tx.execs[0], "UPDATE audittestnote SET") || !strings.HasSuffix(tx.execs[0], "WHERE id = ?") {
+		t.Fatalf("Expected the note to be trashed on its own, got %v", tx.execs)
+	}
+	if args := tx.args[1]; args[0] != "audittestnote" || args[1] != "5" || args[2] != "deleted" {
+		t.Errorf("Expected the cascaded delete to be audited, got %v", args)
+	}
+}
+
+func TestAudit_History(t *testing.T) {
+	orm, _ := setupAuditORM(t,
+		[]string{"id", "table_name", "record_id", "action", "old_values", "new_values", "actor", "created_at"},
+		[]driver.Value{int64(1), "audittestaccount", "7", "created", nil, `{"name":"ada"}`, "alice", "2026-10-18 09:00:00"},
+		[]driver.Value{int64(2), "audittestaccount", "7", "updated", `{"name":"ada"}`, `{"name":"grace"}`, nil, time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)},
+	)
+
+	entries, err := orm.Repository(&AuditTestAccount{}).History(7)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected two entries, got %+v", entries)
+	}
+
+	first, second := entries[0], entries[1]
+	if first.Action != "created" || first.Actor != "alice" || first.OldValues != nil || first.NewValues["name"] != "ada" {
+		t.Errorf("Unexpected first entry %+v", first)
+	}
+	if first.CreatedAt != time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected the entry time to be parsed, got %v", first.CreatedAt)
+	}
+	if second.Actor != "" || second.OldValues["name"] != "ada" || second.NewValues["name"] != "grace" || second.CreatedAt.Hour() != 10 {
+		t.Errorf("Unexpected second entry %+v", second)
+	}
+}
@@ -0,0 +1,251 @@
+package unit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/cache"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+func TestLRUCache_GetSet(t *testing.T) {
+	c := cache.NewLRU(10)
+
+	if err := c.Set("key", "value", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	value, found := c.Get("key")
+	if !found || value != "value" {
+		t.Errorf("Expected cached value, got %v (found=%v)", value, found)
+	}
+
+	if err := c.Delete("key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if c.Has("key") {
+		t.Error("Deleted key should not be found")
+	}
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewLRU(2)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	// Touch "a" so that "b" becomes the least recently used entry
+	c.Get("a")
+	c.Set("c", 3, 0)
+
+	if !c.Has("a") || !c.Has("c") {
+		t.Error("Recently used entries should be kept")
+	}
+	if c.Has("b") {
+		t.Error("Least recently used entry should be evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRUCache_TTL(t *testing.T) {
+	c := cache.NewLRU(10)
+	c.Set("short", "value", 1)
+
+	if !c.Has("short") {
+		t.Fatal("Entry should be available before its TTL")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if c.Has("short") {
+		t.Error("Entry should expire after its TTL")
+	}
+}
+
+func TestLRUCache_InvalidateTags(t *testing.T) {
+	c := cache.NewLRU(10)
+	c.SetWithTags("users-query", "users", 0, []string{"users"})
+	c.SetWithTags("join-query", "joined", 0, []string{"posts", "users"})
+	c.SetWithTags("posts-query", "posts", 0, []string{"posts"})
+
+	if err := c.InvalidateTags("users"); err != nil {
+		t.Fatalf("InvalidateTags failed: %v", err)
+	}
+
+	if c.Has("users-query") || c.Has("join-query") {
+		t.Error("Entries tagged with users should be invalidated")
+	}
+	if !c.Has("posts-query") {
+		t.Error("Entries not tagged with users should be kept")
+	}
+}
+
+func TestCache_InvalidateUntaggedBackend(t *testing.T) {
+	c := &MockCache{}
+	c.Set("key", "value", 0)
+
+	if err := cache.Invalidate(c, "users"); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if c.Has("key") {
+		t.Error("Caches without tag support should be cleared")
+	}
+}
+
+func TestORM_WithCacheInstallsLRU(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+
+	if orm.GetCache() != nil {
+		t.Fatal("Cache should be disabled by default")
+	}
+
+	orm.WithCache(60)
+	if _, ok := orm.GetCache().(*cache.LRU); !ok {
+		t.Errorf("WithCache should install the LRU cache, got %T", orm.GetCache())
+	}
+}
+
+func TestORM_SetCustomCache(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	custom := &MockCache{}
+
+	orm.SetCache(custom).WithCache(60)
+	if orm.GetCache() != custom {
+		t.Error("WithCache should keep the custom cache backend")
+	}
+}
+
+func TestORM_WritesInvalidateCachedQueries(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	lru := cache.NewLRU(10)
+	orm.SetCache(lru)
+
+	lru.SetWithTags("cached", "rows", 0, []string{"querytestmodel"})
+
+	repo := orm.Repository(&QueryTestModel{})
+	if err := repo.Save(&QueryTestModel{Name: "alice"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if lru.Has("cached") {
+		t.Error("Save should invalidate cached queries for the model's table")
+	}
+}
+
+func TestQueryBuilder_CachesResults(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	lru := cache.NewLRU(10)
+	orm.SetCache(lru)
+
+	if _, err := orm.Query(&QueryTestModel{}).Cache(60).Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if lru.Len() != 1 {
+		t.Fatalf("Expected one cached query, got %d", lru.Len())
+	}
+
+	if _, err := orm.Query(&QueryTestModel{}).Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if lru.Len() != 1 {
+		t.Error("Queries without Cache should not be cached")
+	}
+
+	if err := orm.InvalidateCache("querytestmodel"); err != nil {
+		t.Fatalf("InvalidateCache failed: %v", err)
+	}
+	if lru.Len() != 0 {
+		t.Error("InvalidateCache should drop the cached query")
+	}
+}
+
+type CacheTestUser struct {
+	ID    int             `orm:"pk,auto"`
+	Posts []CacheTestPost `orm:"relation:one_to_many,fk:user_id"`
+}
+
+type CacheTestPost struct {
+	ID     int `orm:"pk,auto"`
+	UserID int `orm:"column:user_id"`
+}
+
+// setupRelationCacheORM returns a cached ORM whose reads all return user 1 with post 1
+func setupRelationCacheORM(t *testing.T) (*connection.ORMImpl, *shardDialect) {
+	db := sql.OpenDB(&tableConnector{columns: []string{"id", "user_id"}, rows: [][]driver.Value{{int64(1), int64(1)}}})
+	t.Cleanup(func() { db.Close() })
+
+	d := &shardDialect{MockDialect: dialect.NewMockDialect(), db: db}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for _, model := range []interface{}{&CacheTestUser{}, &CacheTestPost{}} {
+		if err := orm.RegisterModel(model); err != nil {
+			t.Fatalf("RegisterModel failed: %v", err)
+		}
+	}
+	orm.SetCache(cache.NewLRU(10))
+	return orm, d
+}
+
+func TestQueryBuilder_CacheKeyIncludesRelations(t *testing.T) {
+	orm, d := setupRelationCacheORM(t)
+
+	query := orm.Query(&CacheTestUser{}).Cache(60)
+	plain, err := query.Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	withPosts, err := query.With("Posts", nil).Find()
+	if err != nil {
+		t.Fatalf("Find with relation failed: %v", err)
+	}
+	if _, ok := plain[0]["Posts"]; ok {
+		t.Errorf("A plain Find should not load relations, got %v", plain[0])
+	}
+	if posts, ok := withPosts[0]["Posts"].([]map[string]interface{}); !ok || len(posts) != 1 {
+		t.Errorf("Expected the eager-loaded posts rather than the cached plain rows, got %v", withPosts[0])
+	}
+
+	// Both shapes are cached under keys of their own
+	queries := len(d.queries)
+	cached, err := orm.Query(&CacheTestUser{}).Cache(60).With("Posts", nil).Find()
+	if err != nil || len(d.queries) != queries {
+		t.Fatalf("Expected a cache hit, got %v after %d queries", err, len(d.queries)-queries)
+	}
+	if _, ok := cached[0]["Posts"]; !ok {
+		t.Errorf("Expected the cached rows with posts, got %v", cached[0])
+	}
+
+	// Constraint callbacks cannot be keyed, so those queries always run
+	constrained := func(q interfaces.QueryBuilder) interfaces.QueryBuilder { return q.Where("id", ">", 0) }
+	if _, err := orm.Query(&CacheTestUser{}).Cache(60).With("Posts", constrained).Find(); err != nil {
+		t.Fatalf("Find with constrained relation failed: %v", err)
+	}
+	if len(d.queries) != queries+2 {
+		t.Errorf("Expected the constrained query and its relation to run, got %d queries", len(d.queries)-queries)
+	}
+}
+
+func TestQueryBuilder_CachedRelationsAreCopied(t *testing.T) {
+	orm, _ := setupRelationCacheORM(t)
+
+	first, err := orm.Query(&CacheTestUser{}).Cache(60).With("Posts", nil).Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	first[0]["Posts"].([]map[string]interface{})[0]["user_id"] = int64(99)
+
+	second, err := orm.Query(&CacheTestUser{}).Cache(60).With("Posts", nil).Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if userID := second[0]["Posts"].([]map[string]interface{})[0]["user_id"]; userID != int64(1) {
+		t.Errorf("Editing a result should not change the cached relation, got user_id %v", userID)
+	}
+}
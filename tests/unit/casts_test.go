@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/casts"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type CastTestPrefs struct {
+	Theme string `json:"theme"`
+}
+
+type CastTestProduct struct {
+	ID        int           `orm:"pk,auto"`
+	Active    bool          `orm:"column:active,cast:bool"`
+	Price     string        `orm:"column:price,cast:decimal:2"`
+	Weight    float64       `orm:"column:weight,cast:decimal"`
+	Published time.Time     `orm:"column:published,cast:datetime:02/01/2006 15:04"`
+	Prefs     CastTestPrefs `orm:"column:prefs,cast:json"`
+	Tags      []string      `orm:"column:tags,cast:csv-array"`
+	Code      string        `orm:"column:code,cast:upper"`
+}
+
+// castTestUpper is a custom cast storing codes in upper case and reading them in lower case
+type castTestUpper struct{}
+
+func (castTestUpper) Get(value interface{}, target reflect.Type) (interface{}, error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	return strings.ToLower(fmt.Sprint(value)), nil
+}
+
+func (castTestUpper) Set(value interface{}) (interface{}, error) {
+	return strings.ToUpper(fmt.Sprint(value)), nil
+}
+
+func init() {
+	casts.Register("upper", castTestUpper{})
+}
+
+func setupCastORM(t *testing.T) *connection.ORMImpl {
+	db := sql.OpenDB(&tableConnector{
+		columns: []string{"id", "active", "price", "weight", "published", "prefs", "tags", "code"},
+		rows: [][]driver.Value{{
+			int64(1), int64(1), []byte("9.5"), []byte("1.25"), []byte("09/03/2024 15:30"),
+			[]byte(`{"theme":"dark"}`), []byte("red, blue"), []byte("ABC"),
+		}},
+	})
+	t.Cleanup(func() { db.Close() })
+
+	orm := connection.NewORM(&shardDialect{MockDialect: dialect.NewMockDialect(), db: db})
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&CastTestProduct{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm
+}
+
+func TestCasts_BuiltIns(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value interface{}
+		want  interface{}
+	}{
+		{"bool", int64(0), false},
+		{"bool", []byte("true"), true},
+		{"int", []byte("42"), int64(42)},
+		{"float", "2.5", 2.5},
+		{"decimal:2", []byte("3.14159"), "3.14"},
+		{"datetime", "2024-03-09 15:30:00", time.Date(2024, 3, 9, 15, 30, 0, 0, time.UTC)},
+		{"json", `[1,2]`, []interface{}{float64(1), float64(2)}},
+		{"csv-array", "a,b", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		got, err := casts.Get(tt.spec, tt.value, nil)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%s, %v) = %#v, %v; want %#v", tt.spec, tt.value, got, err, tt.want)
+		}
+	}
+
+	if _, err := casts.Get("nope", "x", nil); err == nil {
+		t.Error("An unknown cast should fail")
+	}
+	if got, err := casts.Set("csv-array", &[]string{"a", "b"}); err != nil || got != "a,b" {
+		t.Errorf("Set should dereference pointers, got %v, %v", got, err)
+	}
+	if got, err := casts.Set("json", (*CastTestPrefs)(nil)); err != nil || got != nil {
+		t.Errorf("A nil pointer should stay nil, got %v, %v", got, err)
+	}
+}
+
+func TestCasts_MapResults(t *testing.T) {
+	orm := setupCastORM(t)
+
+	rows, err := orm.Query(&CastTestProduct{}).Find()
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Find failed: %v, %v", rows, err)
+	}
+	row := rows[0]
+
+	if row["active"] != true || row["price"] != "9.50" || row["code"] != "abc" {
+		t.Errorf("Unexpected casts: %#v", row)
+	}
+	if !reflect.DeepEqual(row["prefs"], map[string]interface{}{"theme": "dark"}) {
+		t.Errorf("Expected decoded prefs, got %#v", row["prefs"])
+	}
+	if !reflect.DeepEqual(row["tags"], []string{"red", "blue"}) {
+		t.Errorf("Expected a tag list, got %#v", row["tags"])
+	}
+	if published, ok := row["published"].(time.Time); !ok || !published.Equal(time.Date(2024, 3, 9, 15, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected the datetime layout to be applied, got %#v", row["published"])
+	}
+}
+
+func TestCasts_StructResults(t *testing.T) {
+	orm := setupCastORM(t)
+
+	found, err := orm.Repository(&CastTestProduct{}).Load(1)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	product := found.(*CastTestProduct)
+
+	if !product.Active || product.Price != "9.50" || product.Weight != 1.25 || product.Code != "abc" {
+		t.Errorf("Unexpected entity: %+v", product)
+	}
+	if product.Prefs.Theme != "dark" || !reflect.DeepEqual(product.Tags, []string{"red", "blue"}) {
+		t.Errorf("Expected prefs and tags to be hydrated, got %+v", product)
+	}
+	if product.Published.Hour() != 15 || product.Published.Day() != 9 {
+		t.Errorf("Unexpected published time: %v", product.Published)
+	}
+}
+
+func TestCasts_Persistence(t *testing.T) {
+	orm, d := setupRecordingORM(t, &CastTestProduct{})
+
+	product := &CastTestProduct{
+		Active:    true,
+		Price:     "10",
+		Weight:    2.5,
+		Published: time.Date(2024, 3, 9, 8, 5, 0, 0, time.UTC),
+		Prefs:     CastTestPrefs{Theme: "light"},
+		Tags:      []string{"a", "b"},
+		Code:      "xyz",
+	}
+	if err := orm.Repository(&CastTestProduct{}).Save(product); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	want := []interface{}{true, "10.00", "2.5", "09/03/2024 08:05", `{"theme":"light"}`, "a,b", "XYZ"}
+	if len(d.args) != 1 || !reflect.DeepEqual(d.args[0], want) {
+		t.Errorf("Expected cast values %#v, got %#v", want, d.args)
+	}
+}
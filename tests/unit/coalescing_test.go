@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/singleflight"
+)
+
+// blockingDialect holds every query until released and counts database calls
+type blockingDialect struct {
+	*recordingDialect
+	queries atomic.Int64
+	release chan struct{}
+}
+
+func (d *blockingDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	d.queries.Add(1)
+	<-d.release
+	return nil, nil
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSingleflight_CollapsesConcurrentCalls(t *testing.T) {
+	var group singleflight.Group
+	release := make(chan struct{})
+	var executions atomic.Int64
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err, _ := group.Do("key", func() (interface{}, error) {
+				executions.Add(1)
+				<-release
+				return "value", nil
+			})
+			if err != nil {
+				t.Errorf("Do failed: %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	waitFor(t, func() bool { return group.Stats().Calls == callers })
+	close(release)
+	wg.Wait()
+
+	if executions.Load() != 1 {
+		t.Errorf("Expected a single execution, got %d", executions.Load())
+	}
+	for i, value := range results {
+		if value != "value" {
+			t.Errorf("Caller %d got %v", i, value)
+		}
+	}
+
+	stats := group.Stats()
+	if stats.Executions != 1 || stats.Deduplicated != callers-1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestSingleflight_SequentialCallsExecuteAgain(t *testing.T) {
+	var group singleflight.Group
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	group.Do("key", fn)
+	value, _, shared := group.Do("key", fn)
+
+	if value != 2 || shared {
+		t.Errorf("Completed calls should not be reused, got %v (shared=%v)", value, shared)
+	}
+}
+
+func TestORM_RequestCoalescing(t *testing.T) {
+	orm, recording := setupRecordingORM(t, &QueryTestModel{})
+	d := &blockingDialect{recordingDialect: recording, release: make(chan struct{})}
+	orm.Dialect = d
+	orm.EnableRequestCoalescing()
+
+	const callers = 4
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := orm.Query(&QueryTestModel{}).Where("name", "=", "alice").Find(); err != nil {
+				t.Errorf("Find failed: %v", err)
+			}
+		}()
+	}
+
+	waitFor(t, func() bool { return orm.CoalescingStats().Calls == callers })
+	close(d.release)
+	wg.Wait()
+
+	if d.queries.Load() != 1 {
+		t.Errorf("Expected one database query, got %d", d.queries.Load())
+	}
+	if stats := orm.CoalescingStats(); stats.Deduplicated != callers-1 {
+		t.Errorf("Expected %d deduplicated reads, got %+v", callers-1, stats)
+	}
+}
+
+func TestORM_RequestCoalescingKeepsRelationsApart(t *testing.T) {
+	orm, recording := setupRecordingORM(t, &CacheTestUser{}, &CacheTestPost{})
+	d := &blockingDialect{recordingDialect: recording, release: make(chan struct{})}
+	orm.Dialect = d
+	orm.EnableRequestCoalescing()
+
+	reads := []func() interfaces.QueryBuilder{
+		func() interfaces.QueryBuilder { return orm.Query(&CacheTestUser{}) },
+		func() interfaces.QueryBuilder { return orm.Query(&CacheTestUser{}).With("Posts", nil) },
+	}
+	var wg sync.WaitGroup
+	for _, read := range reads {
+		wg.Add(1)
+		go func(query interfaces.QueryBuilder) {
+			defer wg.Done()
+			if _, err := query.Find(); err != nil {
+				t.Errorf("Find failed: %v", err)
+			}
+		}(read())
+	}
+
+	waitFor(t, func() bool { return d.queries.Load() == int64(len(reads)) })
+	close(d.release)
+	wg.Wait()
+
+	if stats := orm.CoalescingStats(); stats.Deduplicated != 0 {
+		t.Errorf("Reads loading different relations should not be collapsed, got %+v", stats)
+	}
+}
+
+func TestORM_RequestCoalescingDisabledByDefault(t *testing.T) {
+	orm, recording := setupRecordingORM(t, &QueryTestModel{})
+	d := &blockingDialect{recordingDialect: recording, release: make(chan struct{})}
+	close(d.release)
+	orm.Dialect = d
+
+	for i := 0; i < 2; i++ {
+		if _, err := orm.Query(&QueryTestModel{}).Find(); err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+	}
+
+	if d.queries.Load() != 2 {
+		t.Errorf("Expected every read to hit the database, got %d", d.queries.Load())
+	}
+	if stats := orm.CoalescingStats(); stats.Calls != 0 {
+		t.Errorf("Expected no coalescing stats, got %+v", stats)
+	}
+}
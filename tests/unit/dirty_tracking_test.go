@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+type TrackingTestOrder struct {
+	ID         int  `orm:"pk,auto"`
+	CustomerID *int `orm:"column:customer_id"`
+	Total      int  `orm:"column:total"`
+}
+
+func setupTrackingORM(t *testing.T) (*connection.ORMImpl, *shardDialect) {
+	d := newShardDialect(t,
+		[]driver.Value{int64(1), int64(5), int64(100)},
+		[]driver.Value{int64(2), int64(5), int64(250)},
+	)
+	// The ORM connects the dialect itself
+	d.MockDialect.Close()
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for _, model := range []interface{}{&ShardTestOrder{}, &TrackingTestOrder{}} {
+		if err := orm.RegisterModel(model); err != nil {
+			t.Fatalf("RegisterModel failed: %v", err)
+		}
+	}
+	return orm, d
+}
+
+func TestDirtyTracking_LoadAndChanges(t *testing.T) {
+	orm, _ := setupTrackingORM(t)
+	repo := orm.Repository(&ShardTestOrder{})
+
+	loaded, err := repo.Load(1)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	order := loaded.(*ShardTestOrder)
+	if order.ID != 1 || order.CustomerID != 5 || order.Total != 100 {
+		t.Fatalf("Unexpected entity: %+v", order)
+	}
+	if changes := repo.Changes(order); len(changes) != 0 {
+		t.Errorf("A freshly loaded entity should be clean, got %v", changes)
+	}
+
+	order.Total = 120
+	changes := repo.Changes(order)
+	if !changes.IsDirty("total") || changes.IsDirty("customer_id") {
+		t.Errorf("Only total should be dirty, got %v", changes)
+	}
+	if change := changes["total"]; change.Old != 100 || change.New != 120 {
+		t.Errorf("Expected total 100 -> 120, got %v", change)
+	}
+
+	if changes := repo.Changes(&ShardTestOrder{ID: 1}); changes != nil {
+		t.Errorf("Untracked entities should report nil, got %v", changes)
+	}
+}
+
+func TestDirtyTracking_SaveWritesOnlyChangedColumns(t *testing.T) {
+	orm, d := setupTrackingORM(t)
+	repo := orm.Repository(&ShardTestOrder{})
+
+	loaded, err := repo.LoadBy(map[string]interface{}{"customer_id": 5})
+	if err != nil || len(loaded) != 2 {
+		t.Fatalf("LoadBy failed: %v, %d entities", err, len(loaded))
+	}
+	order := loaded[0].(*ShardTestOrder)
+
+	if err := repo.Save(order); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(d.execs) != 0 {
+		t.Errorf("Saving a clean entity should be a no-op, got %v", d.execs)
+	}
+
+	order.Total = 130
+	if err := repo.Save(order); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expected := "UPDATE shardtestorder SET total = ? WHERE id = ?"
+	if len(d.execs) != 1 || d.execs[0] != expected {
+		t.Errorf("Expected %q, got %v", expected, d.execs)
+	}
+	if repo.Changes(order).IsDirty("total") {
+		t.Error("Saving should reset the snapshot")
+	}
+
+	// Untracked entities keep writing every column
+	if err := repo.Update(&ShardTestOrder{ID: 2, CustomerID: 5, Total: 1}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	expected = "UPDATE shardtestorder SET customer_id = ?, total = ? WHERE id = ?"
+	if last := d.execs[len(d.execs)-1]; last != expected {
+		t.Errorf("Expected %q, got %q", expected, last)
+	}
+}
+
+func TestDirtyTracking_ClearedPointerWritesNull(t *testing.T) {
+	orm, d := setupTrackingORM(t)
+	repo := orm.Repository(&TrackingTestOrder{})
+
+	loaded, err := repo.Load(1)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	order := loaded.(*TrackingTestOrder)
+	if order.CustomerID == nil {
+		t.Fatalf("Expected the customer to be loaded, got %+v", order)
+	}
+
+	order.CustomerID = nil
+	if err := repo.Save(order); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expected := "UPDATE trackingtestorder SET customer_id = ? WHERE id = ?"
+	if len(d.execs) != 1 || d.execs[0] != expected {
+		t.Errorf("Expected %q, got %v", expected, d.execs)
+	}
+
+	// A change to a column updates never write leaves nothing to update
+	order.ID = 3
+	if err := repo.Save(order); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(d.execs) != 1 {
+		t.Errorf("Expected no statement without a column to write, got %v", d.execs)
+	}
+}
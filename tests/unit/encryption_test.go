@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/encryption"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type EncryptionTestPatient struct {
+	ID    int     `orm:"pk,auto"`
+	Email string  `orm:"column:email,encrypted,blind_index:email_bidx"`
+	Notes *string `orm:"column:notes,encrypted"`
+}
+
+func setupKeyRing(t *testing.T) *encryption.KeyRing {
+	ring := encryption.NewKeyRing(bytes.Repeat([]byte("i"), 32))
+	if err := ring.AddKey("k1", bytes.Repeat([]byte("a"), 32)); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	encryption.SetKeyProvider(ring)
+	t.Cleanup(func() { encryption.SetKeyProvider(nil) })
+	return ring
+}
+
+func TestEncryption_RoundTripAndRotation(t *testing.T) {
+	ring := setupKeyRing(t)
+
+	ciphertext, err := encryption.Encrypt([]byte("secret"), "patients.notes")
+	if err != nil || !strings.HasPrefix(ciphertext, encryption.Prefix+"k1:") || strings.Contains(ciphertext, "secret") {
+		t.Fatalf("Unexpected ciphertext %q, %v", ciphertext, err)
+	}
+	if other, _ := encryption.Encrypt([]byte("secret"), "patients.notes"); other == ciphertext {
+		t.Error("Each encryption should use a fresh nonce")
+	}
+	if _, err := encryption.Decrypt(ciphertext, "patients.email"); err == nil {
+		t.Error("A value moved to another column should not decrypt")
+	}
+
+	// Rotating keeps old values readable and re-encrypts them on demand
+	if err := ring.AddKey("k2", bytes.Repeat([]byte("b"), 16)); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if plaintext, err := encryption.Decrypt(ciphertext, "patients.notes"); err != nil || string(plaintext) != "secret" {
+		t.Errorf("Expected the old key to still decrypt, got %q, %v", plaintext, err)
+	}
+	rotated, changed, err := encryption.Rotate(ciphertext, "patients.notes")
+	if err != nil || !changed {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if id, _ := encryption.KeyID(rotated); id != "k2" {
+		t.Errorf("Expected the value to move to k2, got %s", id)
+	}
+
+	encryption.SetKeyProvider(nil)
+	if _, err := encryption.Encrypt([]byte("x"), ""); err != encryption.ErrNoKeyProvider {
+		t.Errorf("Expected ErrNoKeyProvider, got %v", err)
+	}
+}
+
+func TestEncryption_Persistence(t *testing.T) {
+	setupKeyRing(t)
+	orm, d := setupRecordingORM(t, &EncryptionTestPatient{})
+
+	notes := "allergic"
+	if err := orm.Repository(&EncryptionTestPatient{}).Save(&EncryptionTestPatient{Email: "ada@example.com", Notes: &notes}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(d.execs) != 1 || !strings.Contains(d.execs[0], "email_bidx") {
+		t.Fatalf("Expected the blind index to be written, got %v", d.execs)
+	}
+
+	args := d.args[0]
+	index, _ := encryption.BlindIndex([]byte("ada@example.com"), encryption.AAD("encryptiontestpatient", "email"))
+	if len(args) != 3 || args[1] != index {
+		t.Fatalf("Expected the email, its index and the notes, got %v", args)
+	}
+	for _, arg := range []interface{}{args[0], args[2]} {
+		if s, ok := arg.(string); !ok || !encryption.IsEncrypted(s) {
+			t.Errorf("Expected an encrypted value, got %v", arg)
+		}
+	}
+}
+
+func TestEncryption_Lookups(t *testing.T) {
+	setupKeyRing(t)
+	orm, _ := setupRecordingORM(t, &EncryptionTestPatient{})
+
+	query := orm.Query(&EncryptionTestPatient{}).Where("email", "=", "ada@example.com")
+	index, _ := encryption.BlindIndex([]byte("ada@example.com"), encryption.AAD("encryptiontestpatient", "email"))
+	if !strings.Contains(query.GetSQL(), "email_bidx") || len(query.GetArgs()) != 1 || query.GetArgs()[0] != index {
+		t.Errorf("Expected a lookup by blind index, got %s %v", query.GetSQL(), query.GetArgs())
+	}
+
+	if _, err := orm.Query(&EncryptionTestPatient{}).Where("notes", "=", "x").Find(); err == nil {
+		t.Error("Looking up an encrypted column without a blind index should fail")
+	}
+	if _, err := orm.Query(&EncryptionTestPatient{}).Where("email", ">", "a").Find(); err == nil {
+		t.Error("Range lookups on encrypted columns should fail")
+	}
+}
+
+func TestEncryption_Hydration(t *testing.T) {
+	setupKeyRing(t)
+
+	email, _ := encryption.Encrypt([]byte("ada@example.com"), encryption.AAD("encryptiontestpatient", "email"))
+	db := sql.OpenDB(&tableConnector{
+		columns: []string{"id", "email", "email_bidx", "notes"},
+		rows:    [][]driver.Value{{int64(1), []byte(email), []byte("ignored"), []byte("legacy plaintext")}},
+	})
+	t.Cleanup(func() { db.Close() })
+
+	orm := connection.NewORM(&shardDialect{MockDialect: dialect.NewMockDialect(), db: db})
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&EncryptionTestPatient{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+
+	loaded, err := orm.Repository(&EncryptionTestPatient{}).Load(1)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	patient := loaded.(*EncryptionTestPatient)
+	if patient.Email != "ada@example.com" || patient.Notes == nil || *patient.Notes != "legacy plaintext" {
+		t.Errorf("Unexpected entity: %+v", patient)
+	}
+}
@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ESGI-M2/GO/dialect"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/metrics"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestErrors_MySQLMapping(t *testing.T) {
+	d := dialect.NewMySQLDialect()
+
+	unique := d.TranslateError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.email'"})
+	var uniqueErr *interfaces.ErrUniqueViolation
+	if !errors.As(unique, &uniqueErr) || uniqueErr.Constraint != "users.email" {
+		t.Errorf("Expected a unique violation on users.email, got %v", unique)
+	}
+	var driverErr *mysql.MySQLError
+	if !errors.As(unique, &driverErr) {
+		t.Error("The driver error should stay in the chain")
+	}
+
+	foreignKey := d.TranslateError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`app`.`posts`, CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"})
+	if !errors.Is(foreignKey, &interfaces.ErrForeignKeyViolation{Constraint: "fk_posts_user"}) {
+		t.Errorf("Expected a foreign key violation on fk_posts_user, got %v", foreignKey)
+	}
+
+	notNull := d.TranslateError(&mysql.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"})
+	if !errors.Is(notNull, &interfaces.ErrNotNullViolation{Column: "name"}) {
+		t.Errorf("Expected a not null violation on name, got %v", notNull)
+	}
+
+	if err := d.TranslateError(&mysql.MySQLError{Number: 1213}); !errors.Is(err, interfaces.ErrDeadlock) {
+		t.Errorf("Expected ErrDeadlock, got %v", err)
+	}
+	if err := d.TranslateError(mysql.ErrInvalidConn); !errors.Is(err, interfaces.ErrConnection) {
+		t.Errorf("Expected ErrConnection, got %v", err)
+	}
+
+	other := &mysql.MySQLError{Number: 1064}
+	if err := d.TranslateError(other); err != other {
+		t.Errorf("Unmapped errors should be returned unchanged, got %v", err)
+	}
+}
+
+func TestErrors_PostgresMapping(t *testing.T) {
+	d := dialect.NewPostgresDialect()
+
+	tests := []struct {
+		err    *pq.Error
+		target error
+	}{
+		{&pq.Error{Code: "23505", Constraint: "users_email_key"}, &interfaces.ErrUniqueViolation{Constraint: "users_email_key"}},
+		{&pq.Error{Code: "23503", Constraint: "posts_user_id_fkey"}, &interfaces.ErrForeignKeyViolation{Constraint: "posts_user_id_fkey"}},
+		{&pq.Error{Code: "23502", Column: "name"}, &interfaces.ErrNotNullViolation{Column: "name"}},
+		{&pq.Error{Code: "40001"}, interfaces.ErrDeadlock},
+		{&pq.Error{Code: "40P01"}, interfaces.ErrDeadlock},
+		{&pq.Error{Code: "08006"}, interfaces.ErrConnection},
+	}
+	for _, tt := range tests {
+		err := d.TranslateError(tt.err)
+		if !errors.Is(err, tt.target) {
+			t.Errorf("Code %s: expected %v, got %v", tt.err.Code, tt.target, err)
+		}
+	}
+
+	if err := d.TranslateError(&pq.Error{Code: "23505", Constraint: "a"}); errors.Is(err, &interfaces.ErrUniqueViolation{Constraint: "b"}) {
+		t.Error("A named target should only match the same constraint")
+	}
+}
+
+func TestErrors_FindOneNotFound(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+
+	result, err := orm.Query(&QueryTestModel{}).Where("name", "=", "nobody").FindOne()
+	if !errors.Is(err, interfaces.ErrNotFound) || result != nil {
+		t.Errorf("Expected ErrNotFound, got %v, %v", result, err)
+	}
+
+	if _, err := orm.Repository(&QueryTestModel{}).Find(42); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Repository.Find should wrap ErrNotFound, got %v", err)
+	}
+}
+
+func TestErrors_MetricsClasses(t *testing.T) {
+	d := dialect.NewPostgresDialect()
+	tests := map[error]string{
+		d.TranslateError(&pq.Error{Code: "23505"}): "constraint",
+		d.TranslateError(&pq.Error{Code: "40001"}): "deadlock",
+		interfaces.ErrNotFound:                     "no_rows",
+	}
+	for err, expected := range tests {
+		if class := metrics.ClassifyError(err); class != expected {
+			t.Errorf("ClassifyError(%v) = %s, expected %s", err, class, expected)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+// statementRecorder is an interceptor remembering every statement it sees
+type statementRecorder struct {
+	statements []interfaces.Statement
+}
+
+func (r *statementRecorder) intercept(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+	r.statements = append(r.statements, stmt)
+	return next(ctx, stmt)
+}
+
+func (r *statementRecorder) kinds() []interfaces.StatementKind {
+	kinds := make([]interfaces.StatementKind, len(r.statements))
+	for i, stmt := range r.statements {
+		kinds[i] = stmt.Kind
+	}
+	return kinds
+}
+
+func TestInterceptor_WrapsStatements(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	recorder := &statementRecorder{}
+	orm.Use(recorder.intercept)
+
+	if err := orm.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "alice"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := orm.Query(&QueryTestModel{}).Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	kinds := recorder.kinds()
+	if len(kinds) != 2 || kinds[0] != interfaces.StatementExec || kinds[1] != interfaces.StatementQuery {
+		t.Errorf("Expected exec then query, got %v", kinds)
+	}
+	if !strings.HasPrefix(recorder.statements[0].SQL, "INSERT INTO querytestmodel") {
+		t.Errorf("Unexpected intercepted SQL: %s", recorder.statements[0].SQL)
+	}
+}
+
+type interceptorTestKey struct{}
+
+func TestInterceptor_ReceivesTheORMContext(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	var seen interface{}
+	orm.Use(func(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+		seen = ctx.Value(interceptorTestKey{})
+		return next(ctx, stmt)
+	})
+
+	scoped := orm.WithContext(context.WithValue(context.Background(), interceptorTestKey{}, "request-1"))
+	if _, err := scoped.Query(&QueryTestModel{}).Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if seen != "request-1" {
+		t.Errorf("Expected interceptors to receive the ORM's context, got %v", seen)
+	}
+}
+
+func TestInterceptor_WrapsTransactions(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	recorder := &statementRecorder{}
+	orm.Use(recorder.intercept)
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		return tx.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "alice"})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	kinds := recorder.kinds()
+	expected := []interfaces.StatementKind{interfaces.StatementBegin, interfaces.StatementExec, interfaces.StatementCommit}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Errorf("Statement %d: expected %s, got %s", i, expected[i], kinds[i])
+		}
+	}
+	if !recorder.statements[1].InTransaction {
+		t.Error("Statements inside the transaction should be flagged")
+	}
+
+	recorder.statements = nil
+	failure := errors.New("abort")
+	err = orm.Transaction(func(tx interfaces.ORM) error { return failure })
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected transaction error, got %v", err)
+	}
+	if kinds := recorder.kinds(); kinds[len(kinds)-1] != interfaces.StatementRollback {
+		t.Errorf("Expected rollback to be intercepted, got %v", kinds)
+	}
+}
+
+func TestInterceptor_RewritesSQL(t *testing.T) {
+	orm, d := setupRecordingORM(t, &QueryTestModel{})
+	orm.Use(func(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+		stmt.SQL = "/* traced */ " + stmt.SQL
+		return next(ctx, stmt)
+	})
+
+	if err := orm.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "alice"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !strings.HasPrefix(d.execs[0], "/* traced */ INSERT") {
+		t.Errorf("Expected rewritten SQL to reach the dialect, got: %s", d.execs[0])
+	}
+}
+
+func TestInterceptor_OrderAndShortCircuit(t *testing.T) {
+	orm, d := setupRecordingORM(t, &QueryTestModel{})
+	var order []string
+	blocked := errors.New("blocked")
+
+	orm.Use(
+		func(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+			order = append(order, "first")
+			return next(ctx, stmt)
+		},
+		func(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+			order = append(order, "second")
+			return interfaces.StatementResult{}, blocked
+		},
+	)
+
+	err := orm.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "alice"})
+	if !errors.Is(err, blocked) {
+		t.Fatalf("Expected interceptor error, got %v", err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("Interceptors should run in registration order, got %v", order)
+	}
+	if len(d.execs) != 0 {
+		t.Error("A short-circuiting interceptor should keep the statement from the database")
+	}
+}
@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+type FillTestAccount struct {
+	ID        int        `orm:"pk,auto"`
+	Name      string     `orm:"column:name"`
+	Age       int        `orm:"column:age"`
+	Score     *float64   `orm:"column:score"`
+	Active    bool       `orm:"column:active"`
+	BirthDate time.Time  `orm:"column:birth_date"`
+	IsAdmin   bool       `orm:"column:is_admin,guarded"`
+	LastLogin *time.Time `orm:"column:last_login"`
+}
+
+type FillTestProfile struct {
+	ID    int    `orm:"pk,auto"`
+	Bio   string `orm:"column:bio,fillable"`
+	Email string `orm:"column:email"`
+}
+
+func TestMassAssignment_Metadata(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &FillTestAccount{}, &FillTestProfile{})
+
+	account, _ := orm.GetMetadata(&FillTestAccount{})
+	if !reflect.DeepEqual(account.Guarded, []string{"is_admin"}) {
+		t.Errorf("Expected is_admin to be guarded, got %v", account.Guarded)
+	}
+	profile, _ := orm.GetMetadata(&FillTestProfile{})
+	if !reflect.DeepEqual(profile.Fillable, []string{"bio"}) {
+		t.Errorf("Expected bio to be fillable, got %v", profile.Fillable)
+	}
+}
+
+func TestMassAssignment_FillConvertsValues(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &FillTestAccount{})
+	repo := orm.Repository(&FillTestAccount{})
+
+	account := &FillTestAccount{}
+	err := repo.Fill(account, map[string]interface{}{
+		"name":       "alice",
+		"age":        float64(31), // JSON numbers decode as float64
+		"score":      "9.5",
+		"active":     "true",
+		"birth_date": "1993-04-01T00:00:00Z",
+		"last_login": nil,
+	})
+	if err != nil {
+		t.Fatalf("Fill failed: %v", err)
+	}
+
+	if account.Name != "alice" || account.Age != 31 || account.Score == nil || *account.Score != 9.5 || !account.Active {
+		t.Errorf("Unexpected entity: %+v", account)
+	}
+	if !account.BirthDate.Equal(time.Date(1993, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected birth date: %v", account.BirthDate)
+	}
+
+	if err := repo.Fill(account, map[string]interface{}{"age": 1.5}); err == nil {
+		t.Error("A fractional age should not be accepted")
+	}
+}
+
+func TestMassAssignment_RejectsProtectedKeys(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &FillTestAccount{}, &FillTestProfile{})
+
+	account := &FillTestAccount{}
+	err := orm.Repository(&FillTestAccount{}).Fill(account, map[string]interface{}{
+		"name":     "mallory",
+		"is_admin": true,
+		"id":       1,
+		"nickname": "m",
+	})
+	var massErr *interfaces.ErrMassAssignment
+	if !errors.As(err, &massErr) || !reflect.DeepEqual(massErr.Keys, []string{"id", "is_admin", "nickname"}) {
+		t.Fatalf("Expected id, is_admin and nickname to be rejected, got %v", err)
+	}
+	if account.Name != "" || account.IsAdmin {
+		t.Errorf("A rejected payload should not set any field, got %+v", account)
+	}
+
+	// Once a column is fillable, the others are protected
+	profile := &FillTestProfile{}
+	err = orm.Repository(&FillTestProfile{}).Fill(profile, map[string]interface{}{"bio": "hi", "email": "x@y.z"})
+	if !errors.Is(err, &interfaces.ErrMassAssignment{Table: "filltestprofile"}) {
+		t.Errorf("Expected email to be rejected, got %v", err)
+	}
+}
+
+func TestMassAssignment_CreateFrom(t *testing.T) {
+	orm, d := setupRecordingORM(t, &FillTestProfile{})
+
+	created, err := orm.Repository(&FillTestProfile{}).CreateFrom(map[string]interface{}{"bio": "hello"})
+	if err != nil {
+		t.Fatalf("CreateFrom failed: %v", err)
+	}
+	if profile, ok := created.(*FillTestProfile); !ok || profile.Bio != "hello" {
+		t.Errorf("Unexpected entity: %#v", created)
+	}
+	if len(d.execs) != 1 {
+		t.Errorf("Expected one insert, got %v", d.execs)
+	}
+
+	if _, err := orm.Repository(&FillTestProfile{}).CreateFrom(map[string]interface{}{"email": "x@y.z"}); err == nil {
+		t.Error("CreateFrom should reject protected keys")
+	}
+	if len(d.execs) != 1 {
+		t.Errorf("A rejected payload should not be inserted, got %v", d.execs)
+	}
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/metrics"
+)
+
+func TestMetrics_RecordsQueriesByOperationAndTable(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	collector := metrics.Instrument(orm)
+
+	if err := orm.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "alice"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := orm.Query(&QueryTestModel{}).Find(); err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+	}
+
+	snapshot := collector.Snapshot()
+	counts := make(map[string]int64)
+	for _, series := range snapshot.Queries {
+		counts[series.Operation+" "+series.Table] = series.Count
+	}
+	if counts["insert querytestmodel"] != 1 || counts["select querytestmodel"] != 2 {
+		t.Errorf("Unexpected query series: %v", counts)
+	}
+	if snapshot.Pool != nil {
+		t.Error("The mock dialect has no connection pool to observe")
+	}
+}
+
+func TestMetrics_CountsTransactionsAndErrors(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	collector := metrics.Instrument(orm)
+
+	orm.Transaction(func(tx interfaces.ORM) error { return nil })
+	orm.Transaction(func(tx interfaces.ORM) error { return errors.New("abort") })
+
+	orm.Use(func(ctx context.Context, stmt interfaces.Statement, next interfaces.StatementHandler) (interfaces.StatementResult, error) {
+		return interfaces.StatementResult{}, fmt.Errorf("dial: %w", driver.ErrBadConn)
+	})
+	orm.Query(&QueryTestModel{}).Find()
+
+	snapshot := collector.Snapshot()
+	if tx := snapshot.Transactions; tx.Begun != 2 || tx.Committed != 1 || tx.RolledBack != 1 {
+		t.Errorf("Unexpected transaction stats: %+v", tx)
+	}
+	if snapshot.Errors["connection"] != 1 {
+		t.Errorf("Expected one connection error, got %v", snapshot.Errors)
+	}
+}
+
+func TestMetrics_PrometheusHandler(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+	collector := metrics.Instrument(orm)
+	orm.Query(&QueryTestModel{}).Find()
+
+	handler := metrics.NewPrometheusHandler(collector)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE orm_query_duration_seconds histogram",
+		`orm_query_duration_seconds_count{operation="select",table="querytestmodel"} 1`,
+		`orm_query_duration_seconds_bucket{operation="select",table="querytestmodel",le="+Inf"} 1`,
+		`orm_transactions_total{outcome="committed"} 0`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, body)
+		}
+	}
+}
+
+func TestMetrics_ExpvarExporter(t *testing.T) {
+	collector := metrics.NewCollector()
+	exporter := metrics.NewExpvarExporter("orm_metrics_test")
+
+	if err := collector.Export(exporter); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := collector.Export(exporter); err == nil {
+		t.Error("Publishing the same name twice should fail")
+	}
+
+	var snapshot metrics.Snapshot
+	if err := json.Unmarshal([]byte(expvar.Get("orm_metrics_test").String()), &snapshot); err != nil {
+		t.Fatalf("Expected a JSON snapshot: %v", err)
+	}
+}
+
+func TestMetrics_ClassifyError(t *testing.T) {
+	tests := map[error]string{
+		context.DeadlineExceeded:                 "timeout",
+		fmt.Errorf("wrap: %w", context.Canceled): "canceled",
+		driver.ErrBadConn:                        "connection",
+		errors.New("syntax error"):               "query",
+	}
+	for err, expected := range tests {
+		if class := metrics.ClassifyError(err); class != expected {
+			t.Errorf("ClassifyError(%v) = %s, expected %s", err, class, expected)
+		}
+	}
+}
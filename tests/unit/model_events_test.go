@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/events"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+)
+
+type EventTestPost struct {
+	ID    int    `orm:"pk,auto"`
+	Title string `orm:"column:title"`
+}
+
+// eventLog collects delivered events from any goroutine
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) listener(event events.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprintf("%s:%s:%v", event.Type, event.Table, event.ID))
+	return nil
+}
+
+func (l *eventLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+func TestModelEvents_SyncListeners(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &SoftDeleteTestUser{}, &EventTestPost{})
+	log := &eventLog{}
+	orm.Events().Listen(&SoftDeleteTestUser{}, events.All, log.listener)
+
+	repo := orm.Repository(&SoftDeleteTestUser{})
+	user := &SoftDeleteTestUser{ID: 1, Name: "alice"}
+	if err := repo.Update(user); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.SoftDelete(user); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if err := repo.Restore(user); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := repo.Delete(user); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := orm.Repository(&EventTestPost{}).Save(&EventTestPost{Title: "other model"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	want := []string{
+		"updated:softdeletetestuser:1", "deleted:softdeletetestuser:1",
+		"restored:softdeletetestuser:1", "deleted:softdeletetestuser:1",
+	}
+	if got := log.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+
+	orm.Events().Listen(nil, events.Created, func(event events.Event) error {
+		return errors.New("title taken")
+	})
+	err := orm.Repository(&EventTestPost{}).Save(&EventTestPost{Title: "dup"})
+	if err == nil || !strings.Contains(err.Error(), "created listener failed: title taken") {
+		t.Errorf("Expected the listener error, got %v", err)
+	}
+}
+
+func TestModelEvents_SyncErrorRollsBack(t *testing.T) {
+	orm, tx := setupSavepointORM(t)
+	if err := orm.RegisterModel(&EventTestPost{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	orm.Events().Listen(&EventTestPost{}, events.Created, func(event events.Event) error {
+		panic("boom")
+	})
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		return tx.Repository(&EventTestPost{}).Save(&EventTestPost{Title: "hello"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "listener panicked: boom") {
+		t.Fatalf("Expected the panic as an error, got %v", err)
+	}
+	if last := tx.statements[len(tx.statements)-1]; last != "ROLLBACK" {
+		t.Errorf("Expected a rollback, got %v", tx.statements)
+	}
+
+	// Outside a transaction the write gets one, so the listener can still undo it
+	tx.statements = nil
+	if err := orm.Repository(&EventTestPost{}).Save(&EventTestPost{Title: "hello"}); err == nil {
+		t.Fatal("Expected the listener error")
+	}
+	if len(tx.statements) != 2 || !strings.HasPrefix(tx.statements[0], "INSERT") || tx.statements[1] != "ROLLBACK" {
+		t.Errorf("Expected the insert to roll back, got %v", tx.statements)
+	}
+}
+
+func TestModelEvents_AsyncAfterCommit(t *testing.T) {
+	orm, _ := setupSavepointORM(t)
+	if err := orm.RegisterModel(&EventTestPost{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	log := &eventLog{}
+	var titles []string
+	orm.Events().ListenAsync(&EventTestPost{}, events.All, func(event events.Event) error {
+		titles = append(titles, event.Entity.(*EventTestPost).Title)
+		return log.listener(event)
+	})
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		repo := tx.Repository(&EventTestPost{})
+		post := &EventTestPost{ID: 7, Title: "first"}
+		for i := 0; i < 3; i++ {
+			post.Title = fmt.Sprintf("v%d", i)
+			if err := repo.Update(post); err != nil {
+				return err
+			}
+		}
+		if len(log.get()) != 0 {
+			t.Error("Async listeners should wait for the commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	_ = orm.Transaction(func(tx interfaces.ORM) error {
+		if err := tx.Repository(&EventTestPost{}).Update(&EventTestPost{ID: 8}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+
+	orm.Events().Close()
+	want := []string{"updated:eventtestpost:7", "updated:eventtestpost:7", "updated:eventtestpost:7"}
+	if got := log.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected only committed events, got %v", got)
+	}
+	if strings.Join(titles, ",") != "v0,v1,v2" {
+		t.Errorf("Expected per-entity ordering with entity snapshots, got %v", titles)
+	}
+}
+
+func TestModelEvents_AsyncErrorsReported(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &EventTestPost{})
+
+	var mu sync.Mutex
+	var reported []error
+	orm.Events().OnError(func(event events.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	})
+	orm.Events().ListenAsync(nil, events.Updated, func(event events.Event) error {
+		panic("async boom")
+	})
+	orm.Events().ListenAsync(nil, events.Updated, func(event events.Event) error {
+		return errors.New("mailer down")
+	})
+
+	if err := orm.Repository(&EventTestPost{}).Update(&EventTestPost{ID: 1}); err != nil {
+		t.Fatalf("Async listener failures should not fail the operation, got %v", err)
+	}
+	orm.Events().Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || !strings.Contains(reported[0].Error(), "async boom") || reported[1].Error() != "mailer down" {
+		t.Errorf("Expected both failures to be reported, got %v", reported)
+	}
+}
+
+func TestModelEvents_AsyncListenersCanWriteTheirOwnEntity(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &EventTestPost{})
+	repo := orm.Repository(&EventTestPost{})
+
+	var mu sync.Mutex
+	delivered := 0
+	orm.Events().ListenAsync(&EventTestPost{}, events.Updated, func(event events.Event) error {
+		mu.Lock()
+		delivered++
+		first := delivered == 1
+		mu.Unlock()
+		if !first {
+			return nil
+		}
+		// Each update queues an event on the worker running this listener
+		for i := 0; i < 500; i++ {
+			if err := repo.Update(&EventTestPost{ID: 1, Title: "touched"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err := repo.Update(&EventTestPost{ID: 1}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		orm.Events().Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The listener's own events should not block its worker")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 501 {
+		t.Errorf("Expected every event to be delivered, got %d", delivered)
+	}
+}
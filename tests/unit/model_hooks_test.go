@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type hookTestKey struct{}
+
+type HookTestAccount struct {
+	ID   int    `orm:"pk,auto"`
+	Name string `orm:"column:name"`
+
+	calls         []string `orm:"-"`
+	sawTx         bool     `orm:"-"`
+	sawContextVal string   `orm:"-"`
+}
+
+func (a *HookTestAccount) BeforeCreate(ctx context.Context, tx interfaces.ORM) error {
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	a.Name = strings.ToLower(a.Name)
+	a.calls = append(a.calls, "BeforeCreate")
+	a.sawTx = tx.(interface{ InTransaction() bool }).InTransaction()
+	a.sawContextVal, _ = ctx.Value(hookTestKey{}).(string)
+	return nil
+}
+
+func (a *HookTestAccount) AfterCreate(ctx context.Context, tx interfaces.ORM) error {
+	a.calls = append(a.calls, "AfterCreate")
+	return nil
+}
+
+func (a *HookTestAccount) AfterFind(ctx context.Context, tx interfaces.ORM) error {
+	a.Name = strings.ToUpper(a.Name)
+	return nil
+}
+
+type HookTestBadSignature struct {
+	ID int `orm:"pk,auto"`
+}
+
+func (HookTestBadSignature) BeforeSave(entity interface{}) error { return nil }
+
+func TestModelHooks_DiscoveredAtRegistration(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &HookTestAccount{})
+
+	metadata, _ := orm.GetMetadata(&HookTestAccount{})
+	if metadata.Hooks == nil || len(metadata.Hooks.Methods) != 3 || metadata.Hooks.Methods["AfterFind"] == nil {
+		t.Fatalf("Expected three hook methods, got %+v", metadata.Hooks)
+	}
+
+	if err := orm.RegisterModel(&HookTestBadSignature{}); err == nil || !strings.Contains(err.Error(), "BeforeSave") {
+		t.Errorf("A hook method with the wrong signature should be rejected, got %v", err)
+	}
+}
+
+func TestModelHooks_ErrorAbortsOperation(t *testing.T) {
+	orm, d := setupRecordingORM(t, &HookTestAccount{})
+
+	account := &HookTestAccount{Name: "Ada"}
+	if err := orm.Repository(&HookTestAccount{}).BatchCreate([]interface{}{account}); err != nil {
+		t.Fatalf("BatchCreate failed: %v", err)
+	}
+	if account.Name != "ada" || strings.Join(account.calls, ",") != "BeforeCreate,AfterCreate" || account.sawTx {
+		t.Errorf("Unexpected hook calls: %+v", account)
+	}
+
+	err := orm.Repository(&HookTestAccount{}).BatchCreate([]interface{}{&HookTestAccount{}})
+	if err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("Expected the hook error, got %v", err)
+	}
+	if len(d.execs) != 1 {
+		t.Errorf("A failing hook should stop the insert, got %v", d.execs)
+	}
+}
+
+func TestModelHooks_ReceiveContextAndTransaction(t *testing.T) {
+	base, _ := setupSavepointORM(t)
+	if err := base.RegisterModel(&HookTestAccount{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), hookTestKey{}, "request-42")
+	account := &HookTestAccount{Name: "Bob"}
+	err := base.WithContext(ctx).Transaction(func(tx interfaces.ORM) error {
+		return tx.Repository(&HookTestAccount{}).BatchCreate([]interface{}{account})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if !account.sawTx || account.sawContextVal != "request-42" {
+		t.Errorf("Expected the hook to see the transaction and context, got %+v", account)
+	}
+}
+
+func TestModelHooks_AfterFind(t *testing.T) {
+	db := sql.OpenDB(&tableConnector{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "ada"}}})
+	t.Cleanup(func() { db.Close() })
+
+	orm := connection.NewORM(&shardDialect{MockDialect: dialect.NewMockDialect(), db: db})
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&HookTestAccount{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+
+	repo := orm.Repository(&HookTestAccount{})
+	loaded, err := repo.Load(1)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.(*HookTestAccount).Name != "ADA" {
+		t.Errorf("Expected AfterFind to run, got %+v", loaded)
+	}
+	if changes := repo.Changes(loaded); len(changes) != 0 {
+		t.Errorf("Changes made by AfterFind should not count as dirty, got %v", changes)
+	}
+}
@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type VersionedTestDocument struct {
+	ID      int    `orm:"pk,auto"`
+	Title   string `orm:"column:title"`
+	Version int    `orm:"column:version,version"`
+}
+
+// versionDialect records statements and reports a configurable number of affected rows
+type versionDialect struct {
+	*dialect.MockDialect
+	execs    []string
+	args     [][]interface{}
+	affected int64
+}
+
+func (d *versionDialect) Exec(query string, args ...interface{}) (sql.Result, error) {
+	d.execs = append(d.execs, query)
+	d.args = append(d.args, args)
+	return driver.RowsAffected(d.affected), nil
+}
+
+func setupVersionORM(t *testing.T) (*connection.ORMImpl, *versionDialect) {
+	d := &versionDialect{MockDialect: dialect.NewMockDialect(), affected: 1}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&VersionedTestDocument{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm, d
+}
+
+func TestOptimisticLock_Metadata(t *testing.T) {
+	orm, _ := setupVersionORM(t)
+	metadata, _ := orm.GetMetadata(&VersionedTestDocument{})
+	if metadata.Version != "version" {
+		t.Errorf("Expected version column, got %q", metadata.Version)
+	}
+}
+
+func TestOptimisticLock_UpdateChecksAndBumpsVersion(t *testing.T) {
+	orm, d := setupVersionORM(t)
+	repo := orm.Repository(&VersionedTestDocument{})
+
+	doc := &VersionedTestDocument{Title: "draft"}
+	if err := repo.Save(doc); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if doc.Version != 1 {
+		t.Errorf("New records should start at version 1, got %d", doc.Version)
+	}
+
+	doc.ID = 7
+	doc.Title = "final"
+	if err := repo.Save(doc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	last := len(d.execs) - 1
+	expected := "UPDATE versionedtestdocument SET title = ?, version = ? WHERE id = ? AND version = ?"
+	if d.execs[last] != expected {
+		t.Errorf("Expected %q, got %q", expected, d.execs[last])
+	}
+	if args := []interface{}{"final", int64(2), 7, int64(1)}; !reflect.DeepEqual(d.args[last], args) {
+		t.Errorf("Expected args %v, got %v", args, d.args[last])
+	}
+	if doc.Version != 2 {
+		t.Errorf("Version should be bumped to 2, got %d", doc.Version)
+	}
+}
+
+func TestOptimisticLock_StaleWrites(t *testing.T) {
+	orm, d := setupVersionORM(t)
+	repo := orm.Repository(&VersionedTestDocument{})
+	d.affected = 0
+
+	doc := &VersionedTestDocument{ID: 7, Title: "lost", Version: 3}
+	err := repo.Update(doc)
+	var stale *interfaces.ErrStaleObject
+	if !errors.As(err, &stale) || stale.Version != 3 || stale.ID != 7 {
+		t.Errorf("Expected ErrStaleObject at version 3, got %v", err)
+	}
+	if doc.Version != 3 {
+		t.Errorf("A stale update should leave the version alone, got %d", doc.Version)
+	}
+
+	err = repo.Delete(doc)
+	if !errors.Is(err, &interfaces.ErrStaleObject{Table: "versionedtestdocument"}) {
+		t.Errorf("Expected ErrStaleObject on delete, got %v", err)
+	}
+	expected := "DELETE FROM versionedtestdocument WHERE id = ? AND version = ?"
+	if last := d.execs[len(d.execs)-1]; last != expected {
+		t.Errorf("Expected %q, got %q", expected, last)
+	}
+}
@@ -0,0 +1,195 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/logging"
+)
+
+type QueryLogTestAccount struct {
+	ID       int    `orm:"pk,auto"`
+	Email    string `orm:"column:email"`
+	Password string `orm:"column:password,sensitive"`
+}
+
+func TestQueryLog_RecordsStatements(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryLogTestAccount{})
+	orm.EnableQueryLog()
+
+	repo := orm.Repository(&QueryLogTestAccount{})
+	if err := repo.Save(&QueryLogTestAccount{Email: "alice@example.com", Password: "secret"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := orm.Query(&QueryLogTestAccount{}).Where("email", "=", "alice@example.com").Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	logs := orm.GetQueryLogger().GetLogs()
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 logged statements, got %d", len(logs))
+	}
+	if !strings.HasPrefix(logs[0].SQL, "INSERT INTO querylogtestaccount") {
+		t.Errorf("Expected INSERT to be logged first, got: %s", logs[0].SQL)
+	}
+	if logs[0].Rows != 1 {
+		t.Errorf("Expected 1 affected row, got %d", logs[0].Rows)
+	}
+	if logs[1].Rows != -1 {
+		t.Errorf("Queries should not report affected rows, got %d", logs[1].Rows)
+	}
+}
+
+func TestQueryLog_RedactsSensitiveColumns(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryLogTestAccount{})
+	orm.EnableQueryLog()
+
+	repo := orm.Repository(&QueryLogTestAccount{})
+	if err := repo.Save(&QueryLogTestAccount{Email: "alice@example.com", Password: "secret"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := orm.Query(&QueryLogTestAccount{}).Where("password", "=", "secret").Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	for _, entry := range orm.GetQueryLogger().GetLogs() {
+		for _, arg := range entry.Args {
+			if arg == "secret" {
+				t.Errorf("Sensitive value leaked in %s: %v", entry.SQL, entry.Args)
+			}
+		}
+	}
+
+	insert := orm.GetQueryLogger().GetLogs()[0]
+	if insert.Args[0] != "alice@example.com" || insert.Args[1] != logging.Redacted {
+		t.Errorf("Expected only the password to be redacted, got %v", insert.Args)
+	}
+}
+
+func TestQueryLog_SlowQueryThreshold(t *testing.T) {
+	buffer := logging.NewRingBuffer(10)
+	logger := logging.NewLogger(buffer)
+	logger.SetSlowThreshold(time.Millisecond)
+
+	logger.Record("SELECT 1", nil, time.Now().Add(-5*time.Millisecond), -1, nil)
+	logger.Record("SELECT 2", nil, time.Now(), -1, nil)
+
+	logs := buffer.GetLogs()
+	if !logs[0].Slow {
+		t.Error("Statement above the threshold should be flagged as slow")
+	}
+	if logs[1].Slow {
+		t.Error("Statement below the threshold should not be flagged as slow")
+	}
+}
+
+func TestQueryLog_DisableStopsRecording(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryLogTestAccount{})
+	orm.EnableQueryLog().DisableQueryLog()
+
+	if _, err := orm.Query(&QueryLogTestAccount{}).Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if logs := orm.GetQueryLogger().GetLogs(); len(logs) != 0 {
+		t.Errorf("Expected no logged statements, got %d", len(logs))
+	}
+}
+
+func TestQueryLog_RingBufferKeepsMostRecent(t *testing.T) {
+	buffer := logging.NewRingBuffer(2)
+	for _, sql := range []string{"a", "b", "c"} {
+		buffer.Log(interfaces.QueryLog{SQL: sql})
+	}
+
+	logs := buffer.GetLogs()
+	if len(logs) != 2 || logs[0].SQL != "b" || logs[1].SQL != "c" {
+		t.Errorf("Expected the two most recent entries in order, got %v", logs)
+	}
+
+	buffer.ClearLogs()
+	if len(buffer.GetLogs()) != 0 {
+		t.Error("ClearLogs should empty the buffer")
+	}
+}
+
+func TestQueryLog_SlogSink(t *testing.T) {
+	var out bytes.Buffer
+	sink := logging.NewSlogSink(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	sink.Log(interfaces.QueryLog{SQL: "SELECT 1", Slow: true})
+	sink.Log(interfaces.QueryLog{SQL: "SELECT 2", Error: errors.New("boom")})
+
+	output := out.String()
+	if !strings.Contains(output, "level=WARN msg=\"slow query\"") {
+		t.Errorf("Expected slow query warning, got: %s", output)
+	}
+	if !strings.Contains(output, "level=ERROR msg=\"query failed\"") || !strings.Contains(output, "error=boom") {
+		t.Errorf("Expected failed query error, got: %s", output)
+	}
+}
+
+func TestQueryLog_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	sink, err := logging.NewFileSink(path)
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+
+	orm, _ := setupRecordingORM(t, &QueryLogTestAccount{})
+	orm.AddQueryLogSink(sink).EnableQueryLog()
+	if _, err := orm.Query(&QueryLogTestAccount{}).Where("email", "=", "bob@example.com").Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", data, err)
+	}
+	if !strings.Contains(record["sql"].(string), "FROM querylogtestaccount") {
+		t.Errorf("Unexpected logged SQL: %v", record["sql"])
+	}
+}
+
+func TestQueryLog_RedactionBindings(t *testing.T) {
+	buffer := logging.NewRingBuffer(10)
+	logger := logging.NewLogger(buffer)
+	logger.AddSensitiveColumns("token")
+
+	tests := []struct {
+		sql      string
+		args     []interface{}
+		redacted []bool
+	}{
+		{"UPDATE users SET name = $1, token = $2 WHERE id = $3", []interface{}{"n", "t", 1}, []bool{false, true, false}},
+		{"SELECT * FROM users WHERE users.token IN (?, ?) AND id > ?", []interface{}{"a", "b", 1}, []bool{true, true, false}},
+		{"INSERT INTO users (name, token) VALUES (?, ?), (?, ?)", []interface{}{"a", "b", "c", "d"}, []bool{false, true, false, true}},
+		{"SELECT * FROM users WHERE name = 'token = ?' LIMIT ?", []interface{}{10}, []bool{false}},
+	}
+
+	for _, tt := range tests {
+		buffer.ClearLogs()
+		logger.Record(tt.sql, tt.args, time.Now(), -1, nil)
+		args := buffer.GetLogs()[0].Args
+		for i, redacted := range tt.redacted {
+			if (args[i] == logging.Redacted) != redacted {
+				t.Errorf("%s: argument %d redacted=%v, expected %v", tt.sql, i, args[i] == logging.Redacted, redacted)
+			}
+		}
+	}
+}
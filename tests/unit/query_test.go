@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ESGI-M2/GO/orm/builder"
@@ -171,10 +172,10 @@ func TestQueryBuilder_Find(t *testing.T) {
 func TestQueryBuilder_FindOne(t *testing.T) {
 	qb := setupQueryBuilder()
 	_, err := qb.FindOne()
-	if err != nil {
+	if err != nil && !errors.Is(err, interfaces.ErrNotFound) {
 		t.Errorf("FindOne failed: %v", err)
 	}
-	// result can be nil for empty results
+	// the mock holds no rows, so ErrNotFound is expected
 }
 
 func TestQueryBuilder_Count(t *testing.T) {
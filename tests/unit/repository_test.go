@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ESGI-M2/GO/orm/builder"
@@ -32,7 +33,7 @@ func TestRepository_Find(t *testing.T) {
 	repo := setupRepository()
 	user := &RepoTestUser{Id: 1}
 	_, err := repo.Find(user.Id)
-	if err != nil {
+	if err != nil && !errors.Is(err, interfaces.ErrNotFound) {
 		t.Errorf("Find failed: %v", err)
 	}
 	// Since we're using a mock, user might be nil, which is expected
@@ -75,7 +76,7 @@ func TestRepository_FindOneBy(t *testing.T) {
 		"email": "test@example.com",
 	}
 	_, err := repo.FindOneBy(criteria)
-	if err != nil {
+	if err != nil && !errors.Is(err, interfaces.ErrNotFound) {
 		t.Errorf("FindOneBy failed: %v", err)
 	}
 	// Since we're using a mock, user might be nil, which is expected
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/dialect"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	mockdialect "github.com/ESGI-M2/GO/orm/dialect"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+var errTestDeadlock = errors.New("deadlock")
+
+// deadlockDialect is a mock dialect classifying errTestDeadlock as retryable
+type deadlockDialect struct {
+	*mockdialect.MockDialect
+}
+
+func (d *deadlockDialect) IsRetryable(err error) bool {
+	return errors.Is(err, errTestDeadlock)
+}
+
+func setupDeadlockORM(t *testing.T) *connection.ORMImpl {
+	orm := connection.NewORM(&deadlockDialect{MockDialect: mockdialect.NewMockDialect()})
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return orm
+}
+
+func TestRetry_DialectClassifiers(t *testing.T) {
+	mysqlDialect := dialect.NewMySQLDialect()
+	for number, expected := range map[uint16]bool{1213: true, 1205: true, 1062: false} {
+		err := fmt.Errorf("commit: %w", &mysql.MySQLError{Number: number})
+		if mysqlDialect.IsRetryable(err) != expected {
+			t.Errorf("MySQL error %d retryable should be %v", number, expected)
+		}
+	}
+
+	postgresDialect := dialect.NewPostgresDialect()
+	for code, expected := range map[pq.ErrorCode]bool{"40001": true, "40P01": true, "23505": false} {
+		err := fmt.Errorf("commit: %w", &pq.Error{Code: code})
+		if postgresDialect.IsRetryable(err) != expected {
+			t.Errorf("Postgres error %s retryable should be %v", code, expected)
+		}
+	}
+
+	if mysqlDialect.IsRetryable(errors.New("boom")) || postgresDialect.IsRetryable(errors.New("boom")) {
+		t.Error("Errors from other sources should not be retried")
+	}
+}
+
+func TestRetry_RetriesUntilSuccess(t *testing.T) {
+	orm := setupDeadlockORM(t)
+	// Interceptors wrap the dialect; the classifier must still be found
+	orm.Use((&statementRecorder{}).intercept)
+
+	attempts := 0
+	err := orm.TransactionWithRetry(interfaces.RetryOptions{BaseDelay: time.Millisecond}, func(tx interfaces.ORM) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("insert: %w", errTestDeadlock)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TransactionWithRetry failed: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetry_StopsOnPermanentErrorsAndExhaustion(t *testing.T) {
+	orm := setupDeadlockORM(t)
+
+	attempts := 0
+	permanent := errors.New("constraint violation")
+	err := orm.TransactionWithRetry(interfaces.RetryOptions{}, func(tx interfaces.ORM) error {
+		attempts++
+		return permanent
+	})
+	if !errors.Is(err, permanent) || attempts != 1 {
+		t.Errorf("Permanent errors should not be retried, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = orm.TransactionWithRetry(interfaces.RetryOptions{MaxAttempts: 4, BaseDelay: time.Millisecond}, func(tx interfaces.ORM) error {
+		attempts++
+		return errTestDeadlock
+	})
+	if !errors.Is(err, errTestDeadlock) || attempts != 4 {
+		t.Errorf("Expected the last error after 4 attempts, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetry_CustomClassifierAndCancellation(t *testing.T) {
+	orm, _ := setupRecordingORM(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := orm.TransactionWithRetry(interfaces.RetryOptions{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+		Context:     ctx,
+		Retryable:   func(error) bool { return true },
+	}, func(tx interfaces.ORM) error {
+		attempts++
+		cancel()
+		return errors.New("conflict")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Cancellation should stop the backoff, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetry_DefaultsToTheORMContext(t *testing.T) {
+	orm, _ := setupRecordingORM(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := orm.WithContext(ctx).TransactionWithRetry(interfaces.RetryOptions{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+		Retryable:   func(error) bool { return true },
+	}, func(tx interfaces.ORM) error {
+		attempts++
+		return errors.New("conflict")
+	})
+	if err == nil || attempts > 1 {
+		t.Errorf("A cancelled ORM context should stop the retries, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetry_RunsOnceInsideATransaction(t *testing.T) {
+	orm, tx := setupSavepointORM(t)
+
+	attempts := 0
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		return outer.TransactionWithRetry(interfaces.RetryOptions{
+			BaseDelay: time.Millisecond,
+			Retryable: func(error) bool { return true },
+		}, func(inner interfaces.ORM) error {
+			attempts++
+			return errTestDeadlock
+		})
+	})
+	if !errors.Is(err, errTestDeadlock) || attempts != 1 {
+		t.Errorf("A nested transaction should not be retried, got %v after %d attempts", err, attempts)
+	}
+	expected := []string{"SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK"}
+	if !reflect.DeepEqual(tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, tx.statements)
+	}
+}
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/builder"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/routing"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+// readCountingDialect counts the reads it serves, optionally slowing them down
+type readCountingDialect struct {
+	*dialect.MockDialect
+	reads atomic.Int64
+	delay time.Duration
+}
+
+func newReadCountingDialect(t *testing.T, delay time.Duration) *readCountingDialect {
+	d := &readCountingDialect{MockDialect: dialect.NewMockDialect(), delay: delay}
+	if err := d.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return d
+}
+
+func (d *readCountingDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	d.reads.Add(1)
+	time.Sleep(d.delay)
+	return d.MockDialect.Query(query, args...)
+}
+
+func setupRoutingORM(t *testing.T, replicas ...interfaces.Dialect) (*connection.ORMImpl, *readCountingDialect) {
+	// The ORM connects the primary through the router
+	primary := &readCountingDialect{MockDialect: dialect.NewMockDialect()}
+	router := routing.NewDialect(primary, nil)
+	for _, replica := range replicas {
+		router.AddReplica(replica)
+	}
+
+	orm := connection.NewORM(router)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&QueryTestModel{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm, primary
+}
+
+func TestRouting_IsRead(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM users":                     true,
+		"  /* traced */ select id FROM users":     true,
+		"SELECT * FROM users FOR UPDATE":          false,
+		"SELECT * FROM users LOCK IN SHARE MODE":  false,
+		"UPDATE users SET name = ?":               false,
+		"INSERT INTO users (name) VALUES (?)":     false,
+		"WITH recent AS (SELECT 1) DELETE FROM x": false,
+	}
+	for query, expected := range tests {
+		if routing.IsRead(query) != expected {
+			t.Errorf("IsRead(%q) should be %v", query, expected)
+		}
+	}
+}
+
+func TestRouting_RoundRobinReads(t *testing.T) {
+	first, second := newReadCountingDialect(t, 0), newReadCountingDialect(t, 0)
+	orm, primary := setupRoutingORM(t, first, second)
+
+	for i := 0; i < 4; i++ {
+		if _, err := orm.Query(&QueryTestModel{}).Find(); err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+	}
+
+	if first.reads.Load() != 2 || second.reads.Load() != 2 {
+		t.Errorf("Expected reads to alternate, got %d and %d", first.reads.Load(), second.reads.Load())
+	}
+	if primary.reads.Load() != 0 {
+		t.Errorf("Reads should not reach the primary, got %d", primary.reads.Load())
+	}
+}
+
+func TestRouting_PrimaryReads(t *testing.T) {
+	replica := newReadCountingDialect(t, 0)
+	orm, primary := setupRoutingORM(t, replica)
+
+	if _, err := orm.Query(&QueryTestModel{}).UsePrimary().Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if _, err := orm.Query(&QueryTestModel{}).ForUpdate().Find(); err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		_, err := tx.Query(&QueryTestModel{}).Find()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if replica.reads.Load() != 0 {
+		t.Errorf("UsePrimary, locking and in-transaction reads should skip replicas, got %d", replica.reads.Load())
+	}
+	if primary.reads.Load() != 2 {
+		t.Errorf("Expected UsePrimary and locking reads on the primary, got %d", primary.reads.Load())
+	}
+}
+
+func TestRouting_LeastLatency(t *testing.T) {
+	slow, fast := newReadCountingDialect(t, 5*time.Millisecond), newReadCountingDialect(t, 0)
+	router := routing.NewDialect(newReadCountingDialect(t, 0), nil)
+	router.AddReplica(slow)
+	router.AddReplica(fast)
+	router.SetStrategy(routing.LeastLatency)
+
+	for i := 0; i < 5; i++ {
+		router.Query("SELECT 1")
+	}
+
+	if slow.reads.Load() != 1 || fast.reads.Load() != 4 {
+		t.Errorf("Expected the fast replica to take over, got slow=%d fast=%d", slow.reads.Load(), fast.reads.Load())
+	}
+}
+
+func TestRouting_LeastLatencyProbesSlowerReplicas(t *testing.T) {
+	slow, fast := newReadCountingDialect(t, 5*time.Millisecond), newReadCountingDialect(t, 0)
+	router := routing.NewDialect(newReadCountingDialect(t, 0), nil)
+	router.AddReplica(slow)
+	router.AddReplica(fast)
+	router.SetStrategy(routing.LeastLatency)
+
+	for i := 0; i < 16; i++ {
+		router.Query("SELECT 1")
+	}
+	if slow.reads.Load() != 2 {
+		t.Fatalf("Expected the slow replica to be probed again, got %d reads", slow.reads.Load())
+	}
+
+	// Once recovered, the probes bring the replica's average down until it wins reads back
+	slow.delay, fast.delay = 0, 5*time.Millisecond
+	for i := 0; i < 64; i++ {
+		router.Query("SELECT 1")
+	}
+	if slow.reads.Load() < 32 {
+		t.Errorf("Expected the recovered replica to serve most reads, got slow=%d fast=%d", slow.reads.Load(), fast.reads.Load())
+	}
+}
+
+func TestRouting_RejectsUnknownStrategy(t *testing.T) {
+	_, _, _, err := builder.Mock().
+		WithDatabase("app").
+		WithCredentials("user", "secret").
+		WithReplicaStrategy("fastest").
+		Build()
+	if err == nil || !strings.Contains(err.Error(), `unknown replica strategy "fastest"`) {
+		t.Errorf("Expected the unknown strategy to be rejected, got %v", err)
+	}
+
+	router := routing.NewDialect(dialect.NewMockDialect(), nil)
+	if err := router.Connect(interfaces.ConnectionConfig{ReplicaStrategy: "fastest"}); err == nil {
+		t.Error("Connect should reject an unknown strategy")
+	}
+}
+
+func TestRouting_ConnectsConfiguredReplicas(t *testing.T) {
+	var created []*dialect.MockDialect
+	router := routing.NewDialect(dialect.NewMockDialect(), func() interfaces.Dialect {
+		replica := dialect.NewMockDialect()
+		created = append(created, replica)
+		return replica
+	})
+
+	config := builder.Mock().
+		WithDatabase("app").
+		WithCredentials("user", "secret").
+		WithReplica("replica1", 3307).
+		WithReplicaConfig(interfaces.ReplicaConfig{Host: "replica2", Username: "reader"}).
+		GetConfig()
+
+	if err := router.Connect(config); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("Expected 2 replicas, got %d", len(created))
+	}
+
+	replicaConfig := routing.ReplicaConnectionConfig(config, config.Replicas[1])
+	if replicaConfig.Host != "replica2" || replicaConfig.Username != "reader" || replicaConfig.Password != "secret" || replicaConfig.Port != config.Port {
+		t.Errorf("Replica should inherit unset fields from the primary, got %+v", replicaConfig)
+	}
+}
+
+func TestConfigBuilder_ReplicasFromEnv(t *testing.T) {
+	t.Setenv("MYSQL_REPLICAS", "replica1:3307, replica2")
+
+	config := builder.MySQL().FromEnv().GetConfig()
+	if len(config.Replicas) != 2 {
+		t.Fatalf("Expected 2 replicas, got %+v", config.Replicas)
+	}
+	if config.Replicas[0].Host != "replica1" || config.Replicas[0].Port != 3307 || config.Replicas[1].Host != "replica2" {
+		t.Errorf("Unexpected replicas: %+v", config.Replicas)
+	}
+}
@@ -0,0 +1,189 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/transaction"
+	mockdialect "github.com/ESGI-M2/GO/orm/dialect"
+)
+
+// statementTx is a transaction recording its statements, commit and rollback
+type statementTx struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (s *statementTx) record(statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements = append(s.statements, statement)
+}
+
+func (s *statementTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	s.record(query)
+	return nil, nil
+}
+
+func (s *statementTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	s.record(query)
+	return nil, errors.New("no rows")
+}
+
+func (s *statementTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	s.record(query)
+	return nil
+}
+
+func (s *statementTx) Commit() error   { s.record("COMMIT"); return nil }
+func (s *statementTx) Rollback() error { s.record("ROLLBACK"); return nil }
+
+// savepointDialect is a mock dialect handing out a recording transaction
+type savepointDialect struct {
+	*mockdialect.MockDialect
+	tx *statementTx
+}
+
+func (d *savepointDialect) Begin() (interfaces.Transaction, error) {
+	return d.tx, nil
+}
+
+func (d *savepointDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
+	return d.tx, nil
+}
+
+func setupSavepointORM(t *testing.T) (*connection.ORMImpl, *statementTx) {
+	d := &savepointDialect{MockDialect: mockdialect.NewMockDialect(), tx: &statementTx{}}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return orm, d.tx
+}
+
+func TestSavepoint_ReleasesOnSuccess(t *testing.T) {
+	orm, tx := setupSavepointORM(t)
+
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		if err := outer.Transaction(func(inner interfaces.ORM) error {
+			_, err := inner.GetDialect().Exec("UPDATE accounts SET balance = 0")
+			return err
+		}); err != nil {
+			return err
+		}
+		return outer.Transaction(func(inner interfaces.ORM) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	expected := []string{
+		"SAVEPOINT sp_1", "UPDATE accounts SET balance = 0", "RELEASE SAVEPOINT sp_1",
+		"SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_2",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, tx.statements)
+	}
+}
+
+func TestSavepoint_RollsBackOnlyTheNestedWork(t *testing.T) {
+	orm, tx := setupSavepointORM(t)
+	failure := errors.New("nested failure")
+
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		err := outer.Transaction(func(inner interfaces.ORM) error {
+			return inner.Transaction(func(innermost interfaces.ORM) error {
+				return failure
+			})
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected the nested error, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Outer transaction failed: %v", err)
+	}
+
+	expected := []string{
+		"SAVEPOINT sp_1", "SAVEPOINT sp_2",
+		"ROLLBACK TO SAVEPOINT sp_2", "ROLLBACK TO SAVEPOINT sp_1",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, tx.statements)
+	}
+}
+
+func TestSavepoint_HelperFunctionsCompose(t *testing.T) {
+	orm, tx := setupSavepointORM(t)
+
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		if _, err := outer.GetDialect().BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true}); err == nil {
+			t.Error("A savepoint should not accept transaction options")
+		}
+		return transaction.TransactionWithContext(outer.(*connection.ORMImpl), context.Background(), func(inner interfaces.ORM) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	expected := []string{"SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "COMMIT"}
+	if !reflect.DeepEqual(tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, tx.statements)
+	}
+}
+
+// unpreparedTx is a recording transaction that also runs statements without preparing them
+type unpreparedTx struct {
+	*statementTx
+}
+
+func (u unpreparedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	u.record("unprepared " + query)
+	return nil, nil
+}
+
+type unpreparedDialect struct {
+	*mockdialect.MockDialect
+	tx unpreparedTx
+}
+
+func (d *unpreparedDialect) Begin() (interfaces.Transaction, error) {
+	return d.tx, nil
+}
+
+func TestSavepoint_StatementsAreNotPrepared(t *testing.T) {
+	d := &unpreparedDialect{MockDialect: mockdialect.NewMockDialect(), tx: unpreparedTx{&statementTx{}}}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	err := orm.Transaction(func(outer interfaces.ORM) error {
+		outer.Transaction(func(interfaces.ORM) error { return nil })
+		outer.Transaction(func(interfaces.ORM) error { return errors.New("undo") })
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	expected := []string{
+		"unprepared SAVEPOINT sp_1", "unprepared RELEASE SAVEPOINT sp_1",
+		"unprepared SAVEPOINT sp_2", "unprepared ROLLBACK TO SAVEPOINT sp_2",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(d.tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, d.tx.statements)
+	}
+}
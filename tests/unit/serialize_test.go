@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm"
+)
+
+type SerializeTestAuthor struct {
+	ID        int                  `orm:"pk,auto" appends:"display_name"`
+	Name      string               `orm:"column:name"`
+	Password  string               `orm:"column:password,hidden"`
+	Settings  string               `orm:"column:settings,cast:json"`
+	Joined    time.Time            `orm:"column:joined,cast:date"`
+	Posts     []SerializeTestPost  `orm:"relation:one_to_many,fk:author_id"`
+	Profile   *SerializeTestAuthor `orm:"relation:one_to_one,fk:id"`
+	Audit     []SerializeTestPost  `orm:"relation:one_to_many,fk:author_id,hidden"`
+	Followers []SerializeTestPost  `orm:"relation:one_to_many,fk:author_id"`
+}
+
+func (a *SerializeTestAuthor) DisplayName() string {
+	return strings.ToUpper(a.Name)
+}
+
+type SerializeTestPost struct {
+	ID       int    `orm:"pk,auto,visible"`
+	Title    string `orm:"column:title,visible"`
+	AuthorID int    `orm:"column:author_id"`
+	Draft    bool   `orm:"column:draft"`
+}
+
+func newSerializeTestAuthor() *SerializeTestAuthor {
+	return &SerializeTestAuthor{
+		ID:       1,
+		Name:     "ada",
+		Password: "secret",
+		Settings: `{"theme":"dark"}`,
+		Joined:   time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC),
+		Posts:    []SerializeTestPost{{ID: 10, Title: "Notes", AuthorID: 1, Draft: true}},
+		Audit:    []SerializeTestPost{{ID: 11}},
+	}
+}
+
+func TestSerialize_ToMap(t *testing.T) {
+	data, err := orm.ToMap(newSerializeTestAuthor())
+	if err != nil {
+		t.Fatalf("ToMap failed: %v", err)
+	}
+
+	if _, ok := data["password"]; ok {
+		t.Error("Hidden columns should be dropped")
+	}
+	if _, ok := data["audit"]; ok {
+		t.Error("Hidden relations should be dropped")
+	}
+	if data["name"] != "ada" || data["display_name"] != "ADA" {
+		t.Errorf("Expected name and appended display_name, got %v", data)
+	}
+	if !reflect.DeepEqual(data["settings"], map[string]interface{}{"theme": "dark"}) {
+		t.Errorf("The json cast should decode settings, got %#v", data["settings"])
+	}
+	if data["joined"] != "2024-03-09" {
+		t.Errorf("The date cast should format joined, got %v", data["joined"])
+	}
+
+	// Visible columns restrict the related posts
+	posts, ok := data["posts"].([]map[string]interface{})
+	if !ok || len(posts) != 1 || !reflect.DeepEqual(posts[0], map[string]interface{}{"id": 10, "title": "Notes"}) {
+		t.Errorf("Expected the visible columns of loaded posts, got %#v", data["posts"])
+	}
+
+	// Relations that were not loaded are left out
+	if _, ok := data["profile"]; ok {
+		t.Error("A nil relation should be left out")
+	}
+	if _, ok := data["followers"]; ok {
+		t.Error("A nil slice relation should be left out")
+	}
+}
+
+type serializeTestEnvelope struct {
+	Author json.Marshaler `json:"author"`
+}
+
+func TestSerialize_JSON(t *testing.T) {
+	encoded, err := orm.ToJSON([]*SerializeTestAuthor{newSerializeTestAuthor()})
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if strings.Contains(string(encoded), "secret") || !strings.HasPrefix(string(encoded), "[{") {
+		t.Errorf("Unexpected JSON: %s", encoded)
+	}
+
+	encoded, err = json.Marshal(serializeTestEnvelope{Author: orm.Marshaler(newSerializeTestAuthor())})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(encoded), "secret") || !strings.Contains(string(encoded), `"display_name":"ADA"`) {
+		t.Errorf("The marshaler should apply the serialization rules, got %s", encoded)
+	}
+}
+
+type SerializeTestNode struct {
+	ID     int                `orm:"pk,auto"`
+	Parent *SerializeTestNode `orm:"relation:many_to_one,fk:parent_id"`
+	Owner  SerializeTestPost  `orm:"relation:many_to_one,fk:owner_id"`
+}
+
+func TestSerialize_BackReferencesAndZeroStructs(t *testing.T) {
+	parent := &SerializeTestNode{ID: 1, Owner: SerializeTestPost{ID: 10, Title: "Notes"}}
+	child := &SerializeTestNode{ID: 2, Parent: parent}
+	parent.Parent = child
+
+	data, err := orm.ToMap(child)
+	if err != nil {
+		t.Fatalf("ToMap failed: %v", err)
+	}
+	loaded, ok := data["parent"].(map[string]interface{})
+	if !ok || loaded["id"] != 1 {
+		t.Fatalf("Expected the parent to be serialized, got %#v", data["parent"])
+	}
+	if _, ok := loaded["parent"]; ok {
+		t.Error("A reference back to the serialized child should be left out")
+	}
+	if !reflect.DeepEqual(loaded["owner"], map[string]interface{}{"id": 10, "title": "Notes"}) {
+		t.Errorf("Expected the loaded owner, got %#v", loaded["owner"])
+	}
+	if _, ok := data["owner"]; ok {
+		t.Error("A zero struct relation should be left out")
+	}
+}
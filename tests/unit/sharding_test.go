@@ -0,0 +1,281 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/sharding"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type ShardTestOrder struct {
+	ID         int `orm:"pk,auto"`
+	CustomerID int `orm:"column:customer_id"`
+	Total      int `orm:"column:total"`
+}
+
+// tableConnector is a database/sql connector serving a fixed table, answering COUNT(*) with its size
+type tableConnector struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (c *tableConnector) Connect(context.Context) (driver.Conn, error) { return tableConn{c}, nil }
+func (c *tableConnector) Driver() driver.Driver                        { return tableDriver{c} }
+
+type tableDriver struct{ connector *tableConnector }
+
+func (d tableDriver) Open(string) (driver.Conn, error) { return tableConn{d.connector}, nil }
+
+type tableConn struct{ connector *tableConnector }
+
+func (c tableConn) Prepare(query string) (driver.Stmt, error) {
+	return tableStmt{connector: c.connector, query: query}, nil
+}
+func (c tableConn) Close() error              { return nil }
+func (c tableConn) Begin() (driver.Tx, error) { return countingTx{}, nil }
+
+type tableStmt struct {
+	connector *tableConnector
+	query     string
+}
+
+func (s tableStmt) Close() error  { return nil }
+func (s tableStmt) NumInput() int { return -1 }
+func (s tableStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+func (s tableStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "COUNT(*)") {
+		return &tableRows{columns: []string{"count"}, rows: [][]driver.Value{{int64(len(s.connector.rows))}}}, nil
+	}
+	return &tableRows{columns: s.connector.columns, rows: s.connector.rows}, nil
+}
+
+type tableRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *tableRows) Columns() []string { return r.columns }
+func (r *tableRows) Close() error      { return nil }
+func (r *tableRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// shardDialect is a mock shard whose reads come from a fixed table and whose statements are recorded
+type shardDialect struct {
+	*dialect.MockDialect
+	db *sql.DB
+
+	mu      sync.Mutex
+	queries []string
+	execs   []string
+}
+
+func newShardDialect(t *testing.T, rows ...[]driver.Value) *shardDialect {
+	db := sql.OpenDB(&tableConnector{columns: []string{"id", "customer_id", "total"}, rows: rows})
+	t.Cleanup(func() { db.Close() })
+
+	d := &shardDialect{MockDialect: dialect.NewMockDialect(), db: db}
+	if err := d.MockDialect.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return d
+}
+
+func (d *shardDialect) record(list *[]string, query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	*list = append(*list, query)
+}
+
+func (d *shardDialect) Exec(query string, args ...interface{}) (sql.Result, error) {
+	d.record(&d.execs, query)
+	return d.MockDialect.Exec(query, args...)
+}
+
+func (d *shardDialect) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	d.record(&d.queries, query)
+	return d.db.Query(query, args...)
+}
+
+func (d *shardDialect) QueryRow(query string, args ...interface{}) *sql.Row {
+	d.record(&d.queries, query)
+	return d.db.QueryRow(query, args...)
+}
+
+func setupShardedORM(t *testing.T, shards ...interfaces.Dialect) *connection.ORMImpl {
+	cluster := sharding.NewDialect(shards...).ShardBy("shardtestorder", "customer_id", sharding.Range(100))
+	orm := connection.NewORM(cluster)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&ShardTestOrder{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm
+}
+
+func TestSharding_ShardFuncs(t *testing.T) {
+	hash := sharding.Hash()
+	first, _ := hash("customer-42", 4)
+	second, _ := hash("customer-42", 4)
+	if first != second || first < 0 || first >= 4 {
+		t.Errorf("Hash should be stable and in range, got %d and %d", first, second)
+	}
+
+	byRange := sharding.Range(100, 200)
+	for key, expected := range map[int]int{0: 0, 99: 0, 100: 1, 250: 2} {
+		if shard, err := byRange(key, 3); err != nil || shard != expected {
+			t.Errorf("Range(%d) = %d, %v; expected shard %d", key, shard, err, expected)
+		}
+	}
+	if _, err := byRange(250, 2); err == nil {
+		t.Error("Keys past the last shard should be rejected")
+	}
+	if _, err := byRange("abc", 3); err == nil {
+		t.Error("Range sharding should reject non-integer keys")
+	}
+}
+
+func TestSharding_SaveRoutesByShardKey(t *testing.T) {
+	low, high := newShardDialect(t), newShardDialect(t)
+	orm := setupShardedORM(t, low, high)
+	repo := orm.Repository(&ShardTestOrder{})
+
+	if err := repo.Save(&ShardTestOrder{CustomerID: 5, Total: 10}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := repo.Save(&ShardTestOrder{CustomerID: 150, Total: 20}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := repo.Delete(&ShardTestOrder{ID: 1, CustomerID: 150}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if len(low.execs) != 1 || !strings.HasPrefix(low.execs[0], "INSERT") {
+		t.Errorf("Expected one insert on the low shard, got %v", low.execs)
+	}
+	if len(high.execs) != 2 || !strings.HasPrefix(high.execs[1], "DELETE") {
+		t.Errorf("Expected an insert and a delete on the high shard, got %v", high.execs)
+	}
+}
+
+func TestSharding_BulkWritesReachEveryShard(t *testing.T) {
+	low, high := newShardDialect(t), newShardDialect(t)
+	orm := setupShardedORM(t, low, high)
+	repo := orm.Repository(&ShardTestOrder{})
+
+	if err := repo.DeleteBy(map[string]interface{}{"total": 0}); err != nil {
+		t.Fatalf("DeleteBy failed: %v", err)
+	}
+	if err := repo.Increment("total", 5); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	for _, shard := range []*shardDialect{low, high} {
+		if len(shard.execs) != 2 || !strings.HasPrefix(shard.execs[0], "DELETE") || !strings.HasPrefix(shard.execs[1], "UPDATE") {
+			t.Errorf("Expected the delete and the increment on every shard, got %v", shard.execs)
+		}
+	}
+
+	if err := repo.DeleteBy(map[string]interface{}{"customer_id": 150}); err != nil {
+		t.Fatalf("DeleteBy failed: %v", err)
+	}
+	if len(low.execs) != 2 || len(high.execs) != 3 {
+		t.Errorf("Expected a delete by shard key on its shard only, got %v and %v", low.execs, high.execs)
+	}
+}
+
+func TestSharding_FindTargetsSingleShard(t *testing.T) {
+	low, high := newShardDialect(t), newShardDialect(t, []driver.Value{int64(1), int64(150), int64(20)})
+	orm := setupShardedORM(t, low, high)
+
+	results, err := orm.Query(&ShardTestOrder{}).Where("customer_id", "=", 150).Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result, got %d", len(results))
+	}
+	if len(low.queries) != 0 || len(high.queries) != 1 {
+		t.Errorf("Expected only the high shard to be queried, got %d and %d", len(low.queries), len(high.queries))
+	}
+}
+
+func TestSharding_ScatterGatherMergesOrderAndLimit(t *testing.T) {
+	low := newShardDialect(t,
+		[]driver.Value{int64(1), int64(5), int64(90)},
+		[]driver.Value{int64(2), int64(7), int64(40)},
+		[]driver.Value{int64(3), int64(9), int64(10)},
+	)
+	high := newShardDialect(t,
+		[]driver.Value{int64(4), int64(150), int64(70)},
+		[]driver.Value{int64(5), int64(160), int64(50)},
+	)
+	orm := setupShardedORM(t, low, high)
+
+	results, err := orm.Query(&ShardTestOrder{}).OrderBy("total", "DESC").Limit(2).Offset(1).Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["total"] != int64(70) || results[1]["total"] != int64(50) {
+		t.Errorf("Expected totals 70 and 50, got %v", results)
+	}
+	for _, shard := range []*shardDialect{low, high} {
+		if len(shard.queries) != 1 || !strings.HasSuffix(shard.queries[0], "LIMIT 3") {
+			t.Errorf("Each shard should return its first offset+limit rows, got %v", shard.queries)
+		}
+	}
+
+	count, err := orm.Query(&ShardTestOrder{}).Count()
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected counts to add up to 5, got %d", count)
+	}
+}
+
+func TestSharding_MergeComparesDriverValues(t *testing.T) {
+	merged := sharding.Merge([][]map[string]interface{}{
+		{{"total": []byte("9")}, {"total": []byte("30")}},
+		{{"total": []byte("10")}, {"total": nil}},
+	}, []interfaces.OrderBy{{Field: "orders.total", Direction: "ASC"}}, 0, 0)
+
+	expected := []interface{}{nil, "9", "10", "30"}
+	for i, row := range merged {
+		value := row["total"]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		if value != expected[i] {
+			t.Errorf("Row %d: expected %v, got %v", i, expected[i], value)
+		}
+	}
+}
+
+func TestSharding_RejectsTransactions(t *testing.T) {
+	orm := setupShardedORM(t, newShardDialect(t), newShardDialect(t))
+
+	err := orm.Transaction(func(tx interfaces.ORM) error {
+		return tx.Repository(&ShardTestOrder{}).Save(&ShardTestOrder{CustomerID: 5})
+	})
+	if err == nil || !strings.Contains(err.Error(), "inside a transaction") {
+		t.Errorf("Expected sharded writes in a transaction to fail, got %v", err)
+	}
+}
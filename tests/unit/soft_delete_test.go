@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type SoftDeleteTestUser struct {
+	ID        int                  `orm:"pk,auto"`
+	Name      string               `orm:"column:name"`
+	DeletedAt *time.Time           `orm:"column:deleted_at,soft"`
+	Posts     []SoftDeleteTestPost `orm:"relation:one_to_many,fk:user_id,cascade"`
+}
+
+type SoftDeleteTestPost struct {
+	ID        int        `orm:"pk,auto"`
+	UserID    int        `orm:"column:user_id"`
+	DeletedAt *time.Time `orm:"column:deleted_at,soft"`
+}
+
+// recordingDialect wraps the mock dialect and records executed statements
+type recordingDialect struct {
+	*dialect.MockDialect
+	execs   []string
+	args    [][]interface{}
+	missing bool // statements match no row
+}
+
+func (d *recordingDialect) Exec(query string, args ...interface{}) (sql.Result, error) {
+	d.execs = append(d.execs, query)
+	d.args = append(d.args, args)
+	if d.missing {
+		return driver.RowsAffected(0), nil
+	}
+	return d.MockDialect.Exec(query, args...)
+}
+
+func setupRecordingORM(t *testing.T, models ...interface{}) (*connection.ORMImpl, *recordingDialect) {
+	d := &recordingDialect{MockDialect: dialect.NewMockDialect()}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for _, model := range models {
+		if err := orm.RegisterModel(model); err != nil {
+			t.Fatalf("RegisterModel failed: %v", err)
+		}
+	}
+	return orm, d
+}
+
+func TestSoftDelete_DefaultScopeHidesTrashed(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &SoftDeleteTestUser{})
+
+	sql := orm.Query(&SoftDeleteTestUser{}).Where("name", "=", "alice").GetSQL()
+	if !strings.Contains(sql, "softdeletetestuser.deleted_at IS NULL") {
+		t.Errorf("Expected soft delete filter in SQL, got: %s", sql)
+	}
+}
+
+func TestSoftDelete_WithTrashed(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &SoftDeleteTestUser{})
+
+	sql := orm.Query(&SoftDeleteTestUser{}).WithTrashed().GetSQL()
+	if strings.Contains(sql, "deleted_at") {
+		t.Errorf("WithTrashed should not filter on deleted_at, got: %s", sql)
+	}
+}
+
+func TestSoftDelete_OnlyTrashed(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &SoftDeleteTestUser{})
+
+	sql := orm.Query(&SoftDeleteTestUser{}).OnlyTrashed().GetSQL()
+	if !strings.Contains(sql, "softdeletetestuser.deleted_at IS NOT NULL") {
+		t.Errorf("Expected trashed filter in SQL, got: %s", sql)
+	}
+}
+
+func TestSoftDelete_ModelWithoutSoftDeletes(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &QueryTestModel{})
+
+	sql := orm.Query(&QueryTestModel{}).GetSQL()
+	if strings.Contains(sql, "WHERE") {
+		t.Errorf("Models without soft deletes should not be filtered, got: %s", sql)
+	}
+}
+
+func TestSoftDelete_CascadeMetadata(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &SoftDeleteTestUser{})
+
+	metadata, err := orm.GetMetadata(&SoftDeleteTestUser{})
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+
+	relation, ok := metadata.Relations["Posts"]
+	if !ok {
+		t.Fatal("Expected Posts relation")
+	}
+	if !relation.Cascade {
+		t.Error("Expected Posts relation to cascade")
+	}
+}
+
+func TestSoftDelete_CascadesToRelations(t *testing.T) {
+	orm, d := setupRecordingORM(t, &SoftDeleteTestUser{}, &SoftDeleteTestPost{})
+	repo := orm.Repository(&SoftDeleteTestUser{})
+
+	user := &SoftDeleteTestUser{ID: 1, Name: "alice"}
+	if err := repo.SoftDelete(user); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if user.DeletedAt == nil {
+		t.Fatal("SoftDelete should set DeletedAt")
+	}
+
+	last := d.execs[len(d.execs)-1]
+	if !strings.HasPrefix(last, "UPDATE softdeletetestpost SET deleted_at") {
+		t.Errorf("Expected cascade update on posts, got: %s", last)
+	}
+
+	if err := repo.Restore(user); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if user.DeletedAt != nil {
+		t.Error("Restore should clear DeletedAt")
+	}
+
+	last = d.execs[len(d.execs)-1]
+	if !strings.Contains(last, "SET deleted_at = NULL") || !strings.Contains(last, "AND deleted_at = ?") {
+		t.Errorf("Expected cascade restore matching the parent timestamp, got: %s", last)
+	}
+}
+
+func TestSoftDelete_ByCriteria(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	db := sql.OpenDB(&tableConnector{
+		columns: []string{"id", "name", "deleted_at"},
+		rows:    [][]driver.Value{{int64(1), "alice", deletedAt}, {int64(2), "alice", deletedAt}},
+	})
+	defer db.Close()
+
+	d := &storedRows{recordingDialect: &recordingDialect{MockDialect: dialect.NewMockDialect()}, db: db}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for _, model := range []interface{}{&SoftDeleteTestUser{}, &SoftDeleteTestPost{}} {
+		if err := orm.RegisterModel(model); err != nil {
+			t.Fatalf("RegisterModel failed: %v", err)
+		}
+	}
+	repo := orm.Repository(&SoftDeleteTestUser{})
+
+	criteria := map[string]interface{}{"name": "alice"}
+	if err := repo.(interface {
+		SoftDeleteBy(map[string]interface{}) error
+	}).SoftDeleteBy(criteria); err != nil {
+		t.Fatalf("SoftDeleteBy failed: %v", err)
+	}
+	if err := repo.RestoreBy(criteria); err != nil {
+		t.Fatalf("RestoreBy failed: %v", err)
+	}
+
+	// Each user is updated on its own, trashed then restored
+	var trashed, restored []interface{}
+	for i, exec := range d.execs {
+		if !strings.HasPrefix(exec, "UPDATE softdeletetestuser") {
+			continue
+		}
+		args := d.args[i]
+		if args[1] == nil {
+			restored = append(restored, args[2])
+		} else {
+			trashed = append(trashed, args[2])
+		}
+	}
+	if !reflect.DeepEqual(trashed, []interface{}{1, 2}) {
+		t.Errorf("Expected both matching users to be trashed, got %v in %v", trashed, d.execs)
+	}
+	if !reflect.DeepEqual(restored, []interface{}{1, 2}) {
+		t.Errorf("Expected both matching users to be restored, got %v in %v", restored, d.execs)
+	}
+}
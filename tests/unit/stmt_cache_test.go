@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ESGI-M2/GO/dialect"
+)
+
+// countingDriver is a minimal database/sql driver counting prepared statements
+type countingDriver struct {
+	prepares atomic.Int64
+}
+
+func (d *countingDriver) Open(name string) (driver.Conn, error) { return &countingConn{driver: d}, nil }
+
+type countingConn struct{ driver *countingDriver }
+
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	c.driver.prepares.Add(1)
+	return countingStmt{}, nil
+}
+func (c *countingConn) Close() error              { return nil }
+func (c *countingConn) Begin() (driver.Tx, error) { return countingTx{}, nil }
+
+type countingStmt struct{}
+
+func (countingStmt) Close() error  { return nil }
+func (countingStmt) NumInput() int { return -1 }
+func (countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (countingStmt) Query(args []driver.Value) (driver.Rows, error) { return countingRows{}, nil }
+
+type countingTx struct{}
+
+func (countingTx) Commit() error   { return nil }
+func (countingTx) Rollback() error { return nil }
+
+type countingRows struct{}
+
+func (countingRows) Columns() []string              { return []string{"id"} }
+func (countingRows) Close() error                   { return nil }
+func (countingRows) Next(dest []driver.Value) error { return io.EOF }
+
+type countingConnector struct{ driver *countingDriver }
+
+func (c countingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &countingConn{driver: c.driver}, nil
+}
+func (c countingConnector) Driver() driver.Driver { return c.driver }
+
+func openCountingDB(t *testing.T) (*sql.DB, *countingDriver) {
+	d := &countingDriver{}
+	db := sql.OpenDB(countingConnector{driver: d})
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestStmtCache_HitsAndMisses(t *testing.T) {
+	db, d := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 10)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Exec(nil, "SELECT id FROM users WHERE id = ?", 1); err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.Hits != 2 || stats.Size != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if d.prepares.Load() != 1 {
+		t.Errorf("Expected the statement to be prepared once, got %d", d.prepares.Load())
+	}
+}
+
+func TestStmtCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	db, _ := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 2)
+
+	cache.Exec(nil, "SELECT 1")
+	cache.Exec(nil, "SELECT 2")
+	cache.Exec(nil, "SELECT 1")
+	cache.Exec(nil, "SELECT 3")
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Size != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	cache.Exec(nil, "SELECT 1")
+	if cache.Stats().Hits != 2 {
+		t.Error("Recently used statement should survive eviction")
+	}
+}
+
+func TestStmtCache_RebindsInTransaction(t *testing.T) {
+	db, _ := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 10)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if _, err := cache.Exec(tx, "UPDATE users SET name = ?", "alice"); err != nil {
+		t.Fatalf("Exec in transaction failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// The pool-level statement stays usable once the transaction is over
+	if _, err := cache.Exec(nil, "UPDATE users SET name = ?", "bob"); err != nil {
+		t.Fatalf("Exec after commit failed: %v", err)
+	}
+	if cache.Stats().Hits != 1 {
+		t.Errorf("Expected transaction statements to reuse the cache, got %+v", cache.Stats())
+	}
+}
+
+func TestStmtCache_Close(t *testing.T) {
+	db, _ := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 10)
+	cache.Exec(nil, "SELECT 1")
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if cache.Stats().Size != 0 {
+		t.Error("Close should drop every statement")
+	}
+}
+
+func TestStmtCache_EvictionKeepsStatementsInUse(t *testing.T) {
+	db, _ := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 1)
+
+	stmt, release, err := cache.Prepare(nil, "SELECT 1")
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	// Another goroutine evicts the statement before it runs
+	cache.Exec(nil, "SELECT 2")
+	if cache.Stats().Evictions != 1 {
+		t.Fatalf("Expected the statement to be evicted, got %+v", cache.Stats())
+	}
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatalf("An evicted statement in use should stay open, got %v", err)
+	}
+
+	release()
+	if _, err := stmt.Exec(); err == nil || !strings.Contains(err.Error(), "statement is closed") {
+		t.Errorf("Expected the last release to close the evicted statement, got %v", err)
+	}
+}
+
+func TestStmtCache_ClosedCacheIsNotRefilled(t *testing.T) {
+	db, _ := openCountingDB(t)
+	cache := dialect.NewStmtCache(db, 10)
+	cache.Close()
+
+	if _, err := cache.Exec(nil, "SELECT 1"); err != nil {
+		t.Fatalf("Exec on a closed cache failed: %v", err)
+	}
+	if cache.Stats().Size != 0 {
+		t.Error("A closed cache should not keep new statements")
+	}
+}
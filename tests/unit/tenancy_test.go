@@ -0,0 +1,280 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/events"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/tenancy"
+	"github.com/ESGI-M2/GO/orm/dialect"
+)
+
+type TenantTestInvoice struct {
+	ID       int `orm:"pk,auto"`
+	TenantID int `orm:"column:tenant_id,tenant"`
+	Total    int `orm:"column:total"`
+}
+
+type TenantTestCustomer struct {
+	ID        int               `orm:"pk,auto"`
+	TenantID  int               `orm:"column:tenant_id,tenant"`
+	DeletedAt *time.Time        `orm:"column:deleted_at,soft"`
+	Orders    []TenantTestOrder `orm:"relation:one_to_many,fk:customer_id,cascade"`
+}
+
+type TenantTestOrder struct {
+	ID         int        `orm:"pk,auto"`
+	CustomerID int        `orm:"column:customer_id"`
+	TenantID   int        `orm:"column:tenant_id,tenant"`
+	DeletedAt  *time.Time `orm:"column:deleted_at,soft"`
+}
+
+func TestTenancy_Metadata(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &TenantTestInvoice{})
+
+	metadata, _ := orm.GetMetadata(&TenantTestInvoice{})
+	if metadata.Tenant != "tenant_id" {
+		t.Errorf("Expected tenant_id as the tenant column, got %q", metadata.Tenant)
+	}
+}
+
+func TestTenancy_FailsClosedWithoutTenant(t *testing.T) {
+	orm, d := setupRecordingORM(t, &TenantTestInvoice{})
+	repo := orm.Repository(&TenantTestInvoice{})
+
+	if _, err := orm.Query(&TenantTestInvoice{}).Find(); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on read, got %v", err)
+	}
+	if _, err := repo.Count(); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on count, got %v", err)
+	}
+	if err := repo.Save(&TenantTestInvoice{Total: 10}); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on insert, got %v", err)
+	}
+	if err := repo.Update(&TenantTestInvoice{ID: 1, Total: 10}); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on update, got %v", err)
+	}
+	if err := repo.Delete(&TenantTestInvoice{ID: 1}); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on delete, got %v", err)
+	}
+	if err := repo.DeleteBy(map[string]interface{}{"total": 10}); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant on bulk delete, got %v", err)
+	}
+	if len(d.execs) != 0 {
+		t.Errorf("No statement should run without a tenant, got %v", d.execs)
+	}
+
+	// Models without a tenant column are not affected
+	if err := orm.Repository(&EventTestPost{}).Save(&EventTestPost{Title: "shared"}); err != nil {
+		t.Errorf("Expected unscoped models to work without a tenant, got %v", err)
+	}
+}
+
+func TestTenancy_ScopesReads(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &TenantTestInvoice{})
+	scoped := orm.WithContext(tenancy.WithTenant(context.Background(), 7))
+
+	query := scoped.Query(&TenantTestInvoice{}).WhereRaw("total > ? OR total < ?", 100, 10)
+	sql := query.GetSQL()
+	if !strings.Contains(sql, "WHERE tenanttestinvoice.tenant_id = ? AND (total > ? OR total < ?)") {
+		t.Errorf("Expected the tenant condition kept apart from raw conditions, got: %s", sql)
+	}
+	if args := query.GetArgs(); !reflect.DeepEqual(args, []interface{}{7, 100, 10}) {
+		t.Errorf("Expected the tenant as the first argument, got %v", args)
+	}
+
+	bypassed := orm.WithContext(tenancy.WithoutTenant(context.Background()))
+	if sql := bypassed.Query(&TenantTestInvoice{}).GetSQL(); strings.Contains(sql, "tenant_id") {
+		t.Errorf("A bypassed context should not be scoped, got: %s", sql)
+	}
+}
+
+func TestTenancy_ScopesWrites(t *testing.T) {
+	orm, d := setupRecordingORM(t, &TenantTestInvoice{})
+	repo := orm.WithContext(tenancy.WithTenant(context.Background(), 7)).Repository(&TenantTestInvoice{})
+
+	invoice := &TenantTestInvoice{Total: 10}
+	if err := repo.Save(invoice); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if invoice.TenantID != 7 || !reflect.DeepEqual(d.args[0], []interface{}{7, 10}) {
+		t.Errorf("Expected the tenant to be injected, got %+v with %v", invoice, d.args[0])
+	}
+
+	invoice.ID, invoice.TenantID = 1, 8
+	if err := repo.Update(invoice); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Delete(invoice); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.DeleteBy(map[string]interface{}{"total": 10}); err != nil {
+		t.Fatalf("DeleteBy failed: %v", err)
+	}
+	if err := repo.Increment("total", 5); err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+
+	expected := []string{
+		"UPDATE tenanttestinvoice SET total = ? WHERE id = ? AND tenant_id = ?",
+		"DELETE FROM tenanttestinvoice WHERE id = ? AND tenant_id = ?",
+		"DELETE FROM tenanttestinvoice WHERE total = ? AND tenant_id = ?",
+		"UPDATE tenanttestinvoice SET total = total + ? WHERE 1=1 AND tenant_id = ?",
+	}
+	if !reflect.DeepEqual(d.execs[1:], expected) {
+		t.Errorf("Expected statements scoped to the tenant:\n%v\ngot:\n%v", expected, d.execs[1:])
+	}
+	for _, args := range d.args[1:] {
+		if args[len(args)-1] != 7 {
+			t.Errorf("Expected the context's tenant as the last argument, got %v", args)
+		}
+	}
+
+	err := repo.Save(&TenantTestInvoice{TenantID: 8, Total: 10})
+	if err == nil || !strings.Contains(err.Error(), "belongs to tenant 8") {
+		t.Errorf("Inserting another tenant's record should fail, got %v", err)
+	}
+}
+
+func TestTenancy_OtherTenantsRecordsAreNotFound(t *testing.T) {
+	orm, d := setupRecordingORM(t, &TenantTestInvoice{})
+	scoped := orm.WithContext(tenancy.WithTenant(context.Background(), 7))
+	repo := scoped.Repository(&TenantTestInvoice{})
+
+	log := &eventLog{}
+	orm.Events().ListenAsync(&TenantTestInvoice{}, events.All, log.listener)
+
+	// The record exists, but belongs to another tenant
+	d.missing = true
+	invoice := &TenantTestInvoice{ID: 1, TenantID: 7, Total: 10}
+	if err := repo.Update(invoice); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on update, got %v", err)
+	}
+	if err := repo.Delete(invoice); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound on delete, got %v", err)
+	}
+	orm.Events().Close()
+	if fired := log.get(); len(fired) != 0 {
+		t.Errorf("No event should fire for records of other tenants, got %v", fired)
+	}
+}
+
+// postgresPlaceholders is a recording dialect numbering its placeholders like PostgreSQL
+// and remembering the queries it runs
+type postgresPlaceholders struct {
+	*recordingDialect
+	queries []string
+	args    [][]interface{}
+}
+
+func (d *postgresPlaceholders) GetPlaceholder(index int) string {
+	return fmt.Sprintf("$%d", index+1)
+}
+
+func (d *postgresPlaceholders) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	d.queries = append(d.queries, query)
+	d.args = append(d.args, args)
+	return d.recordingDialect.Query(query, args...)
+}
+
+func TestTenancy_NumbersWhereInAfterTheTenant(t *testing.T) {
+	d := &postgresPlaceholders{recordingDialect: &recordingDialect{MockDialect: dialect.NewMockDialect()}}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&TenantTestInvoice{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	scoped := orm.WithContext(tenancy.WithTenant(context.Background(), 7))
+
+	_, err := scoped.Query(&TenantTestInvoice{}).WithoutCache().
+		WhereIn("id", []interface{}{1, 2}).WhereNotIn("total", []interface{}{0}).Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(d.queries) != 1 || !strings.Contains(d.queries[0], "tenant_id = $1 AND (id IN ($2, $3) AND total NOT IN ($4))") {
+		t.Errorf("Expected placeholders numbered after the tenant, got %v", d.queries)
+	}
+	if !reflect.DeepEqual(d.args[0], []interface{}{7, 1, 2, 0}) {
+		t.Errorf("Unexpected arguments %v", d.args[0])
+	}
+}
+
+// storedRows is a recording dialect answering every query from a fixed table
+type storedRows struct {
+	*recordingDialect
+	db *sql.DB
+}
+
+func (d *storedRows) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.Query(query, args...)
+}
+
+func TestTenancy_UnchangedUpdateIsNotRefused(t *testing.T) {
+	db := sql.OpenDB(&tableConnector{columns: []string{"1"}, rows: [][]driver.Value{{int64(1)}}})
+	defer db.Close()
+
+	// MySQL reports no affected row for an update writing the stored values
+	d := &storedRows{recordingDialect: &recordingDialect{MockDialect: dialect.NewMockDialect(), missing: true}, db: db}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&TenantTestInvoice{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	repo := orm.WithContext(tenancy.WithTenant(context.Background(), 7)).Repository(&TenantTestInvoice{})
+
+	if err := repo.Update(&TenantTestInvoice{ID: 1, TenantID: 7, Total: 10}); err != nil {
+		t.Errorf("Updating a visible record with its stored values should succeed, got %v", err)
+	}
+}
+
+func TestTenancy_HistoryIsScoped(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &TenantTestInvoice{})
+
+	if _, err := orm.Repository(&TenantTestInvoice{}).History(1); !errors.Is(err, interfaces.ErrNoTenant) {
+		t.Errorf("Expected ErrNoTenant without a tenant, got %v", err)
+	}
+
+	// The record is not visible to the tenant
+	scoped := orm.WithContext(tenancy.WithTenant(context.Background(), 7))
+	if _, err := scoped.Repository(&TenantTestInvoice{}).History(1); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another tenant's record, got %v", err)
+	}
+}
+
+func TestTenancy_CascadeIsScoped(t *testing.T) {
+	orm, d := setupRecordingORM(t, &TenantTestCustomer{}, &TenantTestOrder{})
+	repo := orm.WithContext(tenancy.WithTenant(context.Background(), 7)).Repository(&TenantTestCustomer{})
+
+	customer := &TenantTestCustomer{ID: 1, TenantID: 7}
+	if err := repo.SoftDelete(customer); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	last := len(d.execs) - 1
+	if !strings.HasPrefix(d.execs[last], "UPDATE tenanttestorder SET") || !strings.HasSuffix(d.execs[last], "AND tenant_id = ?") {
+		t.Errorf("Expected the cascade to be restricted to the tenant, got %s", d.execs[last])
+	}
+	if args := d.args[last]; args[len(args)-1] != 7 {
+		t.Errorf("Expected the tenant as the last argument, got %v", args)
+	}
+
+	if err := repo.Restore(customer); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	last = len(d.execs) - 1
+	if !strings.Contains(d.execs[last], "SET deleted_at = NULL") || !strings.HasSuffix(d.execs[last], "AND tenant_id = ?") {
+		t.Errorf("Expected the cascaded restore to be restricted to the tenant, got %s", d.execs[last])
+	}
+}
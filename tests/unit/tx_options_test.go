@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ESGI-M2/GO/dialect"
+	"github.com/ESGI-M2/GO/orm/core/connection"
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	mockdialect "github.com/ESGI-M2/GO/orm/dialect"
+)
+
+// optionsDialect is a mock dialect recording the options transactions are started with
+type optionsDialect struct {
+	*mockdialect.MockDialect
+	tx   *statementTx
+	opts *sql.TxOptions
+}
+
+func (d *optionsDialect) BeginTx(ctx context.Context, opts *sql.TxOptions) (interfaces.Transaction, error) {
+	d.opts = opts
+	return d.tx, nil
+}
+
+func (d *optionsDialect) SetDeferrable(tx interfaces.Transaction) error {
+	_, err := tx.Exec("SET TRANSACTION DEFERRABLE")
+	return err
+}
+
+func setupOptionsORM(t *testing.T) (*connection.ORMImpl, *optionsDialect) {
+	d := &optionsDialect{MockDialect: mockdialect.NewMockDialect(), tx: &statementTx{}}
+	orm := connection.NewORM(d)
+	if err := orm.Connect(interfaces.ConnectionConfig{}); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := orm.RegisterModel(&QueryTestModel{}); err != nil {
+		t.Fatalf("RegisterModel failed: %v", err)
+	}
+	return orm, d
+}
+
+func TestTxOptions_PassedToDialect(t *testing.T) {
+	orm, d := setupOptionsORM(t)
+
+	err := orm.TransactionWithOptions(context.Background(), interfaces.TxOptions{
+		Isolation:  sql.LevelSerializable,
+		ReadOnly:   true,
+		Deferrable: true,
+	}, func(tx interfaces.ORM) error { return nil })
+	if err != nil {
+		t.Fatalf("TransactionWithOptions failed: %v", err)
+	}
+
+	expected := &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+	if !reflect.DeepEqual(d.opts, expected) {
+		t.Errorf("Expected options %+v, got %+v", expected, d.opts)
+	}
+	if statements := []string{"SET TRANSACTION DEFERRABLE", "COMMIT"}; !reflect.DeepEqual(d.tx.statements, statements) {
+		t.Errorf("Expected %v, got %v", statements, d.tx.statements)
+	}
+
+	if err := orm.TransactionWithContext(context.Background(), func(tx interfaces.ORM) error { return nil }); err != nil {
+		t.Fatalf("TransactionWithContext failed: %v", err)
+	}
+	if d.opts != nil {
+		t.Errorf("Default transactions should not pass options, got %+v", d.opts)
+	}
+}
+
+func TestTxOptions_ReadOnlyRejectsWrites(t *testing.T) {
+	orm, d := setupOptionsORM(t)
+
+	err := orm.TransactionWithOptions(context.Background(), interfaces.TxOptions{ReadOnly: true}, func(tx interfaces.ORM) error {
+		if err := tx.Repository(&QueryTestModel{}).Save(&QueryTestModel{Name: "blocked"}); !errors.Is(err, interfaces.ErrReadOnlyTransaction) {
+			t.Errorf("Save should fail with ErrReadOnlyTransaction, got %v", err)
+		}
+		if _, err := tx.GetDialect().Exec("  delete FROM querytestmodel"); !errors.Is(err, interfaces.ErrReadOnlyTransaction) {
+			t.Errorf("Exec should fail with ErrReadOnlyTransaction, got %v", err)
+		}
+		var id int
+		if err := tx.GetDialect().QueryRow("INSERT INTO querytestmodel (name) VALUES ($1) RETURNING id", "blocked").Scan(&id); !errors.Is(err, interfaces.ErrReadOnlyTransaction) {
+			t.Errorf("QueryRow should fail with ErrReadOnlyTransaction, got %v", err)
+		}
+		// Savepoints inherit the read-only flag
+		return tx.Transaction(func(nested interfaces.ORM) error {
+			if _, err := nested.GetDialect().Exec("UPDATE querytestmodel SET age = 1"); !errors.Is(err, interfaces.ErrReadOnlyTransaction) {
+				t.Errorf("Nested Exec should fail with ErrReadOnlyTransaction, got %v", err)
+			}
+			_, err := nested.GetDialect().Exec("SELECT 1")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("TransactionWithOptions failed: %v", err)
+	}
+
+	expected := []string{"SAVEPOINT sp_1", "SELECT 1", "RELEASE SAVEPOINT sp_1", "COMMIT"}
+	if !reflect.DeepEqual(d.tx.statements, expected) {
+		t.Errorf("Writes should not reach the database, expected %v, got %v", expected, d.tx.statements)
+	}
+}
+
+func TestTxOptions_PostgresDeferrable(t *testing.T) {
+	tx := &statementTx{}
+	if err := dialect.NewPostgresDialect().SetDeferrable(tx); err != nil {
+		t.Fatalf("SetDeferrable failed: %v", err)
+	}
+	if expected := []string{"SET TRANSACTION DEFERRABLE"}; !reflect.DeepEqual(tx.statements, expected) {
+		t.Errorf("Expected %v, got %v", expected, tx.statements)
+	}
+}
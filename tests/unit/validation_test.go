@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ESGI-M2/GO/orm/core/interfaces"
+	"github.com/ESGI-M2/GO/orm/core/validation"
+)
+
+type ValidationTestUser struct {
+	ID      int     `orm:"pk,auto"`
+	Name    string  `orm:"column:name" validate:"required,max=5"`
+	Email   string  `orm:"column:email" validate:"required,email"`
+	Age     int     `orm:"column:age" validate:"min=18"`
+	Website *string `orm:"column:website" validate:"url"`
+	Role    string  `orm:"column:role" validate:"omitempty,oneof=admin member"`
+	Code    string  `orm:"column:code" validate:"omitempty,even"`
+}
+
+func init() {
+	validation.Register("even", func(value reflect.Value, _ string) error {
+		if value.Len()%2 != 0 {
+			return fmt.Errorf("must have an even length")
+		}
+		return nil
+	})
+}
+
+func TestValidation_Metadata(t *testing.T) {
+	orm, _ := setupRecordingORM(t, &ValidationTestUser{})
+
+	metadata, _ := orm.GetMetadata(&ValidationTestUser{})
+	want := interfaces.ValidationRule{Column: "name", Type: "max", Value: "5"}
+	if len(metadata.Validation) != 10 || metadata.Validation[1] != want {
+		t.Errorf("Unexpected rules: %+v", metadata.Validation)
+	}
+}
+
+func TestValidation_SaveRejectsInvalidEntities(t *testing.T) {
+	orm, d := setupRecordingORM(t, &ValidationTestUser{})
+	repo := orm.Repository(&ValidationTestUser{})
+
+	err := repo.Save(&ValidationTestUser{Name: "alexandra", Email: "not-an-email", Age: 12, Role: "root", Code: "abc"})
+	var validationErrs interfaces.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	var got []string
+	for _, fieldErr := range validationErrs {
+		got = append(got, fieldErr.Field+":"+fieldErr.Rule)
+	}
+	want := []string{"name:max", "email:email", "age:min", "role:oneof", "code:even"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected failures %v, got %v", want, got)
+	}
+	if validationErrs[2].Message != "must be at least 18" {
+		t.Errorf("Unexpected message: %q", validationErrs[2].Message)
+	}
+	if !strings.Contains(err.Error(), "name must be at most 5 characters") {
+		t.Errorf("Unexpected error text: %v", err)
+	}
+	if len(d.execs) != 0 {
+		t.Errorf("An invalid entity should not reach the database, got %v", d.execs)
+	}
+
+	// Optional pointers are only checked when set
+	if err := repo.Save(&ValidationTestUser{Name: "ada", Email: "ada@example.com", Age: 36}); err != nil {
+		t.Errorf("Expected a valid entity, got %v", err)
+	}
+	website := "nope"
+	if err := repo.Validate(&ValidationTestUser{Name: "ada", Email: "ada@example.com", Age: 36, Website: &website}); err == nil {
+		t.Error("A set website should be checked")
+	}
+}
+
+func TestValidation_BatchCreateValidatesFirst(t *testing.T) {
+	orm, d := setupRecordingORM(t, &ValidationTestUser{})
+
+	err := orm.Repository(&ValidationTestUser{}).BatchCreate([]interface{}{
+		&ValidationTestUser{Name: "ada", Email: "ada@example.com", Age: 36},
+		&ValidationTestUser{Name: "bob", Age: 40},
+	})
+	var validationErrs interfaces.ValidationErrors
+	if !errors.As(err, &validationErrs) || validationErrs[0].Field != "email" {
+		t.Fatalf("Expected the second record to fail on email, got %v", err)
+	}
+	if len(d.execs) != 0 {
+		t.Errorf("No record should be written when one is invalid, got %v", d.execs)
+	}
+}